@@ -0,0 +1,163 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const metricTypeBoardColumns = "boardColumns"
+
+// boardLabel and columnLabel are the labels of boardColumns metrics.
+const (
+	boardLabel  = "board"
+	columnLabel = "column"
+)
+
+// boardConfiguration selects the Agile board of a boardColumns or sprint
+// metric either by ID or by name.
+type boardConfiguration struct {
+	ID   int    `yaml:"id"`
+	Name string `yaml:"name"`
+
+	// resolved caches the ID of a board configured by name.
+	mu       sync.Mutex
+	resolved int
+}
+
+func validateBoardMetric(m *MetricConfiguration) error {
+	if err := validateBoard(m); err != nil {
+		return err
+	}
+	if m.GroupBy != "" || len(m.Aggregates) > 0 {
+		return errors.New("groupBy and aggregates are not supported for boardColumns metrics")
+	}
+	return nil
+}
+
+// validateBoard checks the board of a metric using the Agile API and adds
+// the board label unless the metric sets it itself.
+func validateBoard(m *MetricConfiguration) error {
+	if m.Board == nil || (m.Board.ID == 0 && m.Board.Name == "") {
+		return errors.Errorf("board.id or board.name is required for %s metrics", m.Type)
+	}
+	if m.Board.ID != 0 && m.Board.Name != "" {
+		return errors.New("board.id and board.name must not be used together")
+	}
+	if m.Labels == nil {
+		m.Labels = make(map[string]string)
+	}
+	if _, ok := m.Labels[boardLabel]; !ok {
+		m.Labels[boardLabel] = m.Board.Name
+		if m.Board.Name == "" {
+			m.Labels[boardLabel] = strconv.Itoa(m.Board.ID)
+		}
+	}
+	return nil
+}
+
+// agileURL returns the URL of the given Agile API path. The Agile API lives
+// next to the REST API the search API is part of.
+func agileURL(cfg *Configuration, p string, params url.Values) string {
+	apiPath := cfg.APIPath
+	if apiPath == "" {
+		apiPath = defaultAPIPath
+	}
+	restPath := path.Dir(path.Dir(path.Dir(apiPath)))
+	u := fmt.Sprintf("%s%s", cfg.BaseURL, path.Join(restPath, "agile/1.0", p))
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	return u
+}
+
+// boardID returns the ID of the configured board. Boards configured by name
+// are only looked up once.
+func (s *endpointScraper) boardID(ctx context.Context, b *boardConfiguration) (int, error) {
+	if b.ID != 0 {
+		return b.ID, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.resolved != 0 {
+		return b.resolved, nil
+	}
+	var boards struct {
+		Values []struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		} `json:"values"`
+	}
+	if err := s.getJSON(ctx, agileURL(s.cfg, "board", url.Values{"name": {b.Name}}), &boards); err != nil {
+		return 0, err
+	}
+	// The name parameter matches substrings, so look for an exact match.
+	for _, board := range boards.Values {
+		if board.Name == b.Name {
+			b.resolved = board.ID
+			return board.ID, nil
+		}
+	}
+	return 0, &scrapeError{reason: errorReasonRequest, err: errors.Errorf("board %q not found", b.Name)}
+}
+
+// boardColumns is the part of a board's configuration mapping its columns
+// to statuses.
+type boardColumns struct {
+	Filter struct {
+		ID string `json:"id"`
+	} `json:"filter"`
+	ColumnConfig struct {
+		Columns []struct {
+			Name     string `json:"name"`
+			Statuses []struct {
+				ID string `json:"id"`
+			} `json:"statuses"`
+		} `json:"columns"`
+	} `json:"columnConfig"`
+}
+
+// scrapeBoardColumns counts the issues in every column of a board. The
+// board's configuration is fetched on every scrape so that changed columns
+// are picked up. Without JQL all issues of the board's filter are counted.
+func (s *endpointScraper) scrapeBoardColumns(ctx context.Context, m MetricConfiguration) (scrapeResult, error) {
+	id, err := s.boardID(ctx, m.Board)
+	if err != nil {
+		return scrapeResult{}, err
+	}
+	var board boardColumns
+	if err := s.getJSON(ctx, agileURL(s.cfg, fmt.Sprintf("board/%d/configuration", id), nil), &board); err != nil {
+		return scrapeResult{}, err
+	}
+	base := m.JQL
+	if strings.TrimSpace(base) == "" {
+		base = fmt.Sprintf("filter = %s", board.Filter.ID)
+	}
+	result := scrapeResult{Groups: make(map[string]float64)}
+	for _, column := range board.ColumnConfig.Columns {
+		// Columns without statuses can't contain any issues.
+		if len(column.Statuses) == 0 {
+			continue
+		}
+		statuses := make([]string, 0, len(column.Statuses))
+		for _, status := range column.Statuses {
+			statuses = append(statuses, status.ID)
+		}
+		columnMetric := m
+		columnMetric.JQL = addCondition(base, fmt.Sprintf("status in (%s)", strings.Join(statuses, ", ")))
+		pr, err := s.search(ctx, searchURL(s.cfg, columnMetric, 0), nil)
+		if err != nil {
+			return result, err
+		}
+		result.Pages++
+		result.Total += pr.Total
+		result.Groups[sanitizeLabelValue(s.cfg.LabelSanitization, column.Name)] += float64(pr.Total)
+	}
+	return result, nil
+}
@@ -0,0 +1,101 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoardColumns(t *testing.T) {
+	columns := atomic.Value{}
+	columns.Store(`[
+		{"name": "To Do", "statuses": [{"id": "1"}]},
+		{"name": "In Progress", "statuses": [{"id": "3"}, {"id": "4"}]},
+		{"name": "Backlog", "statuses": []}
+	]`)
+	counts := map[string]int{
+		"(filter = 42) AND status in (1)":    5,
+		"(filter = 42) AND status in (3, 4)": 2,
+		"(filter = 42) AND status in (6)":    7,
+	}
+	var boardRequests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/agile/1.0/board":
+			atomic.AddInt32(&boardRequests, 1)
+			require.Equal(t, "Team Board", r.URL.Query().Get("name"))
+			fmt.Fprint(w, `{"values": [{"id": 8, "name": "Team Board (old)"}, {"id": 7, "name": "Team Board"}]}`)
+		case "/rest/agile/1.0/board/7/configuration":
+			fmt.Fprintf(w, `{"filter": {"id": "42"}, "columnConfig": {"columns": %s}}`, columns.Load())
+		case "/rest/api/2/search":
+			total, ok := counts[r.URL.Query().Get("jql")]
+			require.True(t, ok, r.URL.Query().Get("jql"))
+			fmt.Fprintf(w, `{"total": %d}`, total)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	cfg, err := LoadConfiguration(writeConfig(t, fmt.Sprintf(`
+baseURL: %s
+metrics:
+  - name: board_issues
+    help: Issues per board column
+    type: boardColumns
+    board:
+      name: Team Board
+`, srv.URL)), false)
+	require.NoError(t, err)
+	reg := prometheus.NewRegistry()
+	require.NoError(t, SetupGauges(reg, reg, cfg.Metrics))
+	m := cfg.Metrics[0]
+
+	s := newEndpointScraper(cfg, &http.Client{})
+	result, err := s.scrape(context.Background(), m)
+	require.NoError(t, err)
+	groups := updateGauge(m, result, nil)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP jira_board_issues Issues per board column
+# TYPE jira_board_issues gauge
+jira_board_issues{board="Team Board",column="In Progress"} 2
+jira_board_issues{board="Team Board",column="To Do"} 5
+`), "jira_board_issues"))
+
+	// Renamed columns are picked up by the next scrape while the board is
+	// only looked up once.
+	columns.Store(`[
+		{"name": "To Do", "statuses": [{"id": "1"}]},
+		{"name": "Doing", "statuses": [{"id": "3"}, {"id": "4"}]},
+		{"name": "Review", "statuses": [{"id": "6"}]}
+	]`)
+	result, err = s.scrape(context.Background(), m)
+	require.NoError(t, err)
+	updateGauge(m, result, groups)
+	require.Equal(t, int32(1), atomic.LoadInt32(&boardRequests))
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP jira_board_issues Issues per board column
+# TYPE jira_board_issues gauge
+jira_board_issues{board="Team Board",column="Doing"} 2
+jira_board_issues{board="Team Board",column="Review"} 7
+jira_board_issues{board="Team Board",column="To Do"} 5
+`), "jira_board_issues"))
+}
+
+func TestValidateBoardMetric(t *testing.T) {
+	m := MetricConfiguration{Type: metricTypeBoardColumns}
+	require.Error(t, validateBoardMetric(&m))
+	m.Board = &boardConfiguration{ID: 3, Name: "Team Board"}
+	require.Error(t, validateBoardMetric(&m))
+	m.Board = &boardConfiguration{ID: 3}
+	require.NoError(t, validateBoardMetric(&m))
+	require.Equal(t, map[string]string{boardLabel: "3"}, m.Labels)
+}
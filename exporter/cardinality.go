@@ -0,0 +1,42 @@
+package exporter
+
+// defaultMaxSeries is the number of groups a grouped metric exports at most
+// if no maxSeries has been configured.
+const defaultMaxSeries = 500
+
+// limitSeries keeps the maxSeries-1 largest groups of a grouped metric and
+// counts all others as otherGroup, so that no more than maxSeries series are
+// exported. If the limit was hit, the number of groups before truncation is
+// returned as well, otherwise 0. Groups dropped this way are removed from
+// the GaugeVec by updateGauge.
+func limitSeries(m MetricConfiguration, result scrapeResult) (scrapeResult, int) {
+	if m.GroupBy == "" || m.MaxSeries <= 0 || len(result.Groups) <= m.MaxSeries {
+		return result, 0
+	}
+	observed := len(result.Groups)
+	if m.MaxSeries == 1 {
+		other := 0.0
+		for _, v := range result.Groups {
+			other += v
+		}
+		result.Groups = map[string]float64{otherGroup: other}
+		return result, observed
+	}
+	result.Groups = topGroups(result.Groups, m.MaxSeries-1)
+	if len(result.Groups) == observed {
+		return result, 0
+	}
+	return result, observed
+}
+
+// setSeriesLimitHit updates the series limit gauge of the given metric.
+func setSeriesLimitHit(m MetricConfiguration, hit bool) {
+	if m.SeriesLimitHit == nil {
+		return
+	}
+	if hit {
+		m.SeriesLimitHit.Set(1)
+	} else {
+		m.SeriesLimitHit.Set(0)
+	}
+}
@@ -0,0 +1,52 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitSeries(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics := []MetricConfiguration{{Name: "by_label", Help: "Issues per label", GroupBy: "labels", MaxSeries: 2}}
+	require.NoError(t, SetupGauges(reg, reg, metrics))
+	m := metrics[0]
+
+	result, observed := limitSeries(m, scrapeResult{Groups: map[string]float64{"a": 1, "b": 2}})
+	require.Zero(t, observed)
+	groups := updateGauge(m, result, nil)
+
+	result, observed = limitSeries(m, scrapeResult{Groups: map[string]float64{"a": 1, "b": 2, "c": 5, "d": 3}})
+	require.Equal(t, 4, observed)
+	require.Len(t, result.Groups, m.MaxSeries)
+	setSeriesLimitHit(m, observed > 0)
+	updateGauge(m, result, groups)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP jira_by_label Issues per label
+# TYPE jira_by_label gauge
+jira_by_label{label="c"} 5
+jira_by_label{label="other"} 6
+# HELP jira_series_limit_hit Whether the last successful scrape of the grouped metric had more groups than its maxSeries
+# TYPE jira_series_limit_hit gauge
+jira_series_limit_hit{metric="by_label"} 1
+`), "jira_by_label", "jira_series_limit_hit"))
+
+	require.Equal(t, m.MaxSeries, testutil.CollectAndCount(m.GaugeVec))
+
+	m.MaxSeries = 1
+	result, observed = limitSeries(m, scrapeResult{Groups: map[string]float64{"a": 1, "b": 2}})
+	require.Equal(t, 2, observed)
+	require.Equal(t, map[string]float64{otherGroup: 3}, result.Groups)
+
+	cfg, err := LoadConfiguration(writeConfig(t, `
+metrics:
+  - name: by_label
+    jql: project = A
+    groupBy: labels
+`), false)
+	require.NoError(t, err)
+	require.Equal(t, defaultMaxSeries, cfg.Metrics[0].MaxSeries)
+}
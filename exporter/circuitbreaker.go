@@ -0,0 +1,188 @@
+package exporter
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultCircuitBreakerThreshold = 5
+	defaultCircuitBreakerCooldown  = 5 * time.Minute
+)
+
+// errRequestAborted is recorded for requests that ended without a result,
+// e.g. because the scrape panicked.
+var errRequestAborted = errors.New("request aborted")
+
+// errCircuitOpen is returned if the circuit breakers of all endpoints are
+// open.
+var errCircuitOpen = errors.New("circuit breaker open")
+
+type circuitBreakerConfiguration struct {
+	// Threshold is the number of consecutive authentication or server
+	// errors after which the breaker opens.
+	Threshold int `yaml:"threshold"`
+	// Cooldown is the time to wait before a single probe request is
+	// allowed through an open breaker.
+	Cooldown       string        `yaml:"cooldown"`
+	ParsedCooldown time.Duration `yaml:"-"`
+}
+
+// circuitBreaker is shared by all workers talking to the same Jira instance.
+// It opens after a number of consecutive authentication or server errors so
+// that a broken Jira (or expired credentials) doesn't get hammered with
+// requests. Once the cooldown has passed, a single probe request is let
+// through which decides whether the breaker closes again.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	log       *logrus.Logger
+	instance  string
+	threshold int
+	cooldown  time.Duration
+	gauge     prometheus.Gauge
+	now       func() time.Time
+
+	failures int
+	open     bool
+	openedAt time.Time
+	probing  bool
+}
+
+func newCircuitBreaker(log *logrus.Logger, instance string, cfg circuitBreakerConfiguration, gauge prometheus.Gauge) *circuitBreaker {
+	b := &circuitBreaker{
+		log:       log,
+		instance:  instance,
+		threshold: cfg.Threshold,
+		cooldown:  cfg.ParsedCooldown,
+		gauge:     gauge,
+		now:       time.Now,
+	}
+	if b.threshold <= 0 {
+		b.threshold = defaultCircuitBreakerThreshold
+	}
+	if b.cooldown <= 0 {
+		b.cooldown = defaultCircuitBreakerCooldown
+	}
+	if b.gauge != nil {
+		b.gauge.Set(0)
+	}
+	return b
+}
+
+// setupCircuitBreakers creates a circuit breaker for every configured Jira
+// endpoint and registers their state gauge, so that a broken replica doesn't
+// keep requests from failing over to the primary instance.
+func setupCircuitBreakers(registry prometheus.Registerer, log *logrus.Logger, cfg *Configuration) ([]*circuitBreaker, error) {
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "jira_circuit_breaker_open",
+		Help: "1 if requests to the Jira instance are currently suspended",
+	}, []string{"instance"})
+	if err := registry.Register(gauge); err != nil {
+		return nil, err
+	}
+	var breakers []*circuitBreaker
+	for i := range endpointConfigs(cfg) {
+		instance := endpointName(cfg, i)
+		breakers = append(breakers, newCircuitBreaker(log, instance, cfg.CircuitBreakerConfig, gauge.WithLabelValues(instance)))
+	}
+	return breakers, nil
+}
+
+// instanceLabel returns the host of the given base URL so that no
+// credentials end up in label values.
+func instanceLabel(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return baseURL
+	}
+	return u.Host
+}
+
+// allow reports whether a request may be sent right now.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open {
+		return true
+	}
+	if b.probing || b.now().Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.probing = true
+	b.log.WithField("instance", b.instance).Warn("Circuit breaker half-open, sending probe request")
+	return true
+}
+
+// suspended reports whether requests are currently refused without taking
+// the probe slot.
+func (b *circuitBreaker) suspended() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.open && (b.probing || b.now().Sub(b.openedAt) < b.cooldown)
+}
+
+// record updates the state of the breaker based on the result of a request.
+func (b *circuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		b.failures = 0
+		b.probing = false
+		if b.open {
+			b.setOpen(false)
+			b.log.WithField("instance", b.instance).Warn("Circuit breaker closed")
+		}
+		return
+	}
+	// Only a successful probe closes the breaker, any other outcome
+	// restarts the cooldown.
+	if b.probing {
+		b.probing = false
+		b.openedAt = b.now()
+		b.log.WithField("instance", b.instance).Warn("Circuit breaker probe failed, staying open")
+		return
+	}
+	if !isTrippingError(err) {
+		return
+	}
+	b.failures++
+	if !b.open && b.failures >= b.threshold {
+		b.setOpen(true)
+		b.openedAt = b.now()
+		b.log.WithField("instance", b.instance).Warnf("Circuit breaker opened after %d consecutive failures", b.failures)
+	}
+}
+
+func (b *circuitBreaker) setOpen(open bool) {
+	b.open = open
+	if b.gauge == nil {
+		return
+	}
+	if open {
+		b.gauge.Set(1)
+	} else {
+		b.gauge.Set(0)
+	}
+}
+
+// isTrippingError reports whether the given error indicates an
+// authentication problem or a server-side failure.
+func isTrippingError(err error) bool {
+	var scrapeErr *scrapeError
+	if !errors.As(err, &scrapeErr) {
+		return false
+	}
+	switch {
+	case scrapeErr.statusCode == http.StatusUnauthorized, scrapeErr.statusCode == http.StatusForbidden:
+		return true
+	case scrapeErr.statusCode >= 500:
+		return true
+	}
+	return false
+}
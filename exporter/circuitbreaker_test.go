@@ -0,0 +1,131 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test"})
+	now := time.Now()
+	b := newCircuitBreaker(log, "jira", circuitBreakerConfiguration{Threshold: 2, ParsedCooldown: time.Minute}, gauge)
+	b.now = func() time.Time { return now }
+
+	unauthorized := &scrapeError{reason: errorReasonStatus, statusCode: http.StatusUnauthorized}
+	notFound := &scrapeError{reason: errorReasonStatus, statusCode: http.StatusNotFound}
+
+	// Errors that are neither auth nor server errors don't trip the breaker.
+	for i := 0; i < 5; i++ {
+		b.record(notFound)
+	}
+	require.True(t, b.allow())
+
+	b.record(unauthorized)
+	require.True(t, b.allow())
+	b.record(unauthorized)
+	require.False(t, b.allow())
+	require.Equal(t, float64(1), testutil.ToFloat64(gauge))
+
+	// After the cooldown only a single probe is let through.
+	now = now.Add(time.Minute)
+	require.True(t, b.allow())
+	require.False(t, b.allow())
+
+	// A failing probe restarts the cooldown.
+	b.record(&scrapeError{reason: errorReasonStatus, statusCode: http.StatusBadGateway})
+	require.False(t, b.allow())
+	now = now.Add(time.Minute)
+	require.True(t, b.allow())
+
+	// Neither does a probe failing for another reason.
+	b.record(notFound)
+	require.False(t, b.allow())
+	require.Equal(t, float64(1), testutil.ToFloat64(gauge))
+	now = now.Add(time.Minute)
+	require.True(t, b.allow())
+
+	// An aborted probe gives up the probe slot.
+	b.record(errRequestAborted)
+	require.False(t, b.allow())
+	now = now.Add(time.Minute)
+	require.True(t, b.allow())
+
+	// A successful probe closes the breaker again.
+	b.record(nil)
+	require.True(t, b.allow())
+	require.True(t, b.allow())
+	require.Equal(t, float64(0), testutil.ToFloat64(gauge))
+}
+
+func TestCircuitBreakerProbeTaken(t *testing.T) {
+	log, hook := logtest.NewNullLogger()
+	log.SetLevel(logrus.DebugLevel)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	requests := int32(0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		fmt.Fprint(w, `{"total": 1}`)
+	}))
+	defer srv.Close()
+
+	cfg, err := LoadConfiguration(writeConfig(t, fmt.Sprintf(`
+baseURL: %s
+login: me
+password: secret
+metrics:
+  - name: test
+    jql: project = A
+`, srv.URL)), false)
+	require.NoError(t, err)
+	reg := prometheus.NewRegistry()
+	require.NoError(t, SetupGauges(reg, reg, cfg.Metrics))
+	cfg.circuitBreakers, err = setupCircuitBreakers(reg, log, cfg)
+	require.NoError(t, err)
+	// The breaker looks half-open to the worker, but another worker takes
+	// the probe slot before it gets to send its request.
+	b := cfg.circuitBreakers[0]
+	b.setOpen(true)
+	openedAt := time.Now()
+	b.openedAt = openedAt
+	calls := 0
+	b.now = func() time.Time {
+		calls++
+		if calls == 1 {
+			return openedAt.Add(b.cooldown)
+		}
+		return openedAt
+	}
+	skipped := func() bool {
+		for _, e := range hook.AllEntries() {
+			if strings.HasPrefix(e.Message, "Skipping") {
+				return true
+			}
+		}
+		return false
+	}
+	go func() {
+		defer cancel()
+		require.Eventually(t, skipped, 5*time.Second, 10*time.Millisecond)
+	}()
+
+	NewScraper(log, cfg, &http.Client{}).Run(ctx)
+	require.Equal(t, int32(0), atomic.LoadInt32(&requests))
+	for _, e := range hook.AllEntries() {
+		require.NotContains(t, e.Message, "Failed to scrape")
+	}
+}
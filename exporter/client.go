@@ -0,0 +1,136 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// jiraClient sends requests to a single Jira endpoint. It adds the user
+// agent, the configured headers and the credentials to every request,
+// limits the size of the responses and turns failures into *scrapeError
+// values. The URLs are built by the callers.
+type jiraClient struct {
+	cfg              *Configuration
+	client           *http.Client
+	userAgent        string
+	maxResponseBytes int64
+}
+
+func newJiraClient(cfg *Configuration, client *http.Client) *jiraClient {
+	c := &jiraClient{
+		cfg:              cfg,
+		client:           client,
+		userAgent:        cfg.UserAgent,
+		maxResponseBytes: cfg.MaxResponseBytes,
+	}
+	if c.userAgent == "" {
+		c.userAgent = defaultUserAgent()
+	}
+	if c.maxResponseBytes <= 0 {
+		c.maxResponseBytes = defaultMaxResponseBytes
+	}
+	return c
+}
+
+func defaultUserAgent() string {
+	return fmt.Sprintf("jiravars/%s", version)
+}
+
+func addHeaders(r *http.Request, headers map[string]string) {
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+}
+
+// get sends an authenticated GET request to Jira. All errors returned are
+// of type *scrapeError.
+func (c *jiraClient) get(ctx context.Context, u string) (*http.Response, error) {
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, &scrapeError{reason: errorReasonRequest, err: errors.Wrap(err, "failed to create HTTP request")}
+	}
+	r.Header.Set("User-Agent", c.userAgent)
+	addHeaders(r, c.cfg.HTTPHeaders)
+	if c.cfg.Auth.Mode != authModeNone {
+		r.SetBasicAuth(c.cfg.Login, c.cfg.Password)
+	}
+	resp, err := c.client.Do(r)
+	if err != nil {
+		return nil, &scrapeError{reason: errorReasonRequest, err: errors.Wrap(err, "failed to execute HTTP request")}
+	}
+	return resp, nil
+}
+
+// getJSON decodes the JSON response of a GET request to Jira into v.
+func (c *jiraClient) getJSON(ctx context.Context, u string, v interface{}) error {
+	resp, err := c.get(ctx, u)
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(resp.Body, c.maxResponseBytes)
+	if resp.StatusCode != http.StatusOK {
+		return &scrapeError{reason: errorReasonStatus, statusCode: resp.StatusCode}
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(nil, resp.Body, c.maxResponseBytes)).Decode(v); err != nil {
+		return &scrapeError{reason: errorReasonDecode, err: errors.Wrap(err, "failed to parse HTTP response")}
+	}
+	return nil
+}
+
+// search executes a single search request and decodes its response.
+func (c *jiraClient) search(ctx context.Context, u string, onIssue func(issue) error) (pagedResponse, error) {
+	var pr pagedResponse
+	resp, err := c.get(ctx, u)
+	if err != nil {
+		return pr, err
+	}
+	defer drainAndClose(resp.Body, c.maxResponseBytes)
+	if resp.StatusCode == http.StatusBadRequest {
+		return pr, &scrapeError{reason: errorReasonStatus, statusCode: resp.StatusCode, message: readErrorMessage(resp.Body)}
+	}
+	if !isSuccessStatus(c.cfg.SuccessStatusCodes, resp.StatusCode) {
+		return pr, &scrapeError{reason: errorReasonStatus, statusCode: resp.StatusCode}
+	}
+	if contentType := resp.Header.Get("Content-Type"); !isJSONContentType(contentType) {
+		return pr, &scrapeError{reason: errorReasonInvalidResponse, err: errors.Errorf("response has unexpected content type %q", contentType)}
+	}
+	pr, err = decodePagedResponse(http.MaxBytesReader(nil, resp.Body, c.maxResponseBytes), onIssue)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return pr, &scrapeError{reason: errorReasonBodyTooLarge, err: errors.Errorf("HTTP response exceeded the limit of %d bytes", c.maxResponseBytes)}
+		}
+		var invalidErr *invalidResponseError
+		if errors.As(err, &invalidErr) {
+			return pr, &scrapeError{reason: errorReasonInvalidResponse, err: err}
+		}
+		return pr, &scrapeError{reason: errorReasonDecode, err: errors.Wrap(err, "failed to parse HTTP response")}
+	}
+	return pr, nil
+}
+
+// isSuccessStatus reports whether the status code is one of the configured
+// success codes or 200 if none have been configured.
+func isSuccessStatus(codes []int, statusCode int) bool {
+	if len(codes) == 0 {
+		return statusCode == http.StatusOK
+	}
+	for _, code := range codes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// drainAndClose reads up to limit remaining bytes of the body before closing
+// it so that the underlying keep-alive connection can be reused.
+func drainAndClose(body io.ReadCloser, limit int64) {
+	io.Copy(io.Discard, io.LimitReader(body, limit))
+	body.Close()
+}
@@ -0,0 +1,62 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJiraClient(t *testing.T) {
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		switch r.URL.Path {
+		case "/ok":
+			fmt.Fprint(w, `{"key": "TEST"}`)
+		case "/search":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"total": 3, "issues": []}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := &Configuration{
+		Login:       "me",
+		Password:    "secret",
+		Auth:        authConfiguration{Mode: authModeBasic},
+		HTTPHeaders: map[string]string{"X-Team": "a"},
+	}
+	c := newJiraClient(cfg, &http.Client{})
+	var v struct {
+		Key string `json:"key"`
+	}
+	require.NoError(t, c.getJSON(context.Background(), srv.URL+"/ok", &v))
+	require.Equal(t, "TEST", v.Key)
+	require.Equal(t, defaultUserAgent(), header.Get("User-Agent"))
+	require.Equal(t, "a", header.Get("X-Team"))
+	login, password, ok := (&http.Request{Header: header}).BasicAuth()
+	require.True(t, ok)
+	require.Equal(t, "me", login)
+	require.Equal(t, "secret", password)
+
+	pr, err := c.search(context.Background(), srv.URL+"/search", nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), pr.Total)
+
+	err = c.getJSON(context.Background(), srv.URL+"/missing", &v)
+	var scrapeErr *scrapeError
+	require.True(t, errors.As(err, &scrapeErr))
+	require.Equal(t, http.StatusNotFound, scrapeErr.statusCode)
+
+	// Anonymous clients don't send credentials.
+	cfg.Auth.Mode = authModeNone
+	require.NoError(t, newJiraClient(cfg, &http.Client{}).getJSON(context.Background(), srv.URL+"/ok", &v))
+	require.Empty(t, header.Get("Authorization"))
+}
@@ -0,0 +1,68 @@
+package exporter
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// clockCheckPeriod is the period at which the wall clock is checked for
+// jumps.
+const clockCheckPeriod = 10 * time.Second
+
+// clockJumpThreshold is the deviation from the check period above which the
+// wall clock is considered to have jumped.
+const clockJumpThreshold = time.Minute
+
+// clockMonitor detects jumps of the wall clock, e.g. after the VM has been
+// suspended or after NTP stepped the clock. Timers are based on the
+// monotonic clock, which doesn't advance while the system is suspended, so
+// without intervention metrics would only be scraped on their next regular
+// tick after a resume.
+type clockMonitor struct {
+	now       func() time.Time
+	threshold time.Duration
+	last      time.Time
+}
+
+// observe returns how far the wall clock deviated from the expected time
+// since the previous call, or 0 if the deviation is below the threshold.
+// Negative values indicate the clock jumped backwards.
+func (c *clockMonitor) observe(expected time.Duration) time.Duration {
+	// Round strips the monotonic reading so that wall clock time is
+	// compared.
+	now := c.now().Round(0)
+	last := c.last
+	c.last = now
+	if last.IsZero() {
+		return 0
+	}
+	jump := now.Sub(last) - expected
+	if jump > -c.threshold && jump < c.threshold {
+		return 0
+	}
+	return jump
+}
+
+// watchClock checks the wall clock every period until ctx is done. On a
+// jump a single line is logged and onJump is called, e.g. to scrape all
+// metrics right away.
+func watchClock(ctx context.Context, log *logrus.Logger, period time.Duration, c *clockMonitor, onJump func()) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	c.observe(0)
+	for {
+		select {
+		case <-ticker.C:
+			jump := c.observe(period)
+			if jump == 0 {
+				continue
+			}
+			log.Infof("The system clock jumped by %s, e.g. after a suspend, scraping all metrics now", jump.Round(time.Second))
+			onJump()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClockMonitor(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := &clockMonitor{now: func() time.Time { return now }, threshold: time.Minute}
+	require.Equal(t, time.Duration(0), c.observe(10*time.Second))
+
+	// Late ticks are not a jump.
+	now = now.Add(15 * time.Second)
+	require.Equal(t, time.Duration(0), c.observe(10*time.Second))
+
+	// A suspend of an hour.
+	now = now.Add(time.Hour + 10*time.Second)
+	require.Equal(t, time.Hour, c.observe(10*time.Second))
+
+	// NTP stepping the clock back.
+	now = now.Add(-5 * time.Minute)
+	require.Equal(t, -5*time.Minute-10*time.Second, c.observe(10*time.Second))
+}
+
+func TestWatchClock(t *testing.T) {
+	log, hook := logtest.NewNullLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	calls := 0
+	c := &clockMonitor{
+		now: func() time.Time {
+			calls++
+			// The third check happens after the VM has been suspended for
+			// two hours.
+			if calls == 3 {
+				now = now.Add(2 * time.Hour)
+			}
+			now = now.Add(10 * time.Millisecond)
+			return now
+		},
+		threshold: time.Minute,
+	}
+	jumps := 0
+	watchClock(ctx, log, 10*time.Millisecond, c, func() {
+		jumps++
+		if jumps == 1 {
+			// Give the monitor a few more ticks before stopping.
+			time.AfterFunc(50*time.Millisecond, cancel)
+		}
+	})
+	require.Equal(t, 1, jumps)
+	require.Len(t, hook.AllEntries(), 1)
+	require.Contains(t, hook.LastEntry().Message, "jumped by 2h0m0s")
+}
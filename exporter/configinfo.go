@@ -0,0 +1,51 @@
+package exporter
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// setupConfigInfo exports what the exporter has been configured to do. Only
+// a hash of the JQL is exported so that no queries end up in label values.
+func setupConfigInfo(registry prometheus.Registerer, metrics []MetricConfiguration) error {
+	configured := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "jiravars_configured_metrics",
+		Help: "Number of configured metrics",
+	})
+	if err := registry.Register(configured); err != nil {
+		return err
+	}
+	info := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "jiravars_metric_info",
+		Help: "Configuration of a metric, always 1",
+	}, []string{"metric", "interval", "type", "groupby", "jql_hash"})
+	if err := registry.Register(info); err != nil {
+		return err
+	}
+	configured.Set(float64(len(metrics)))
+	for _, m := range metrics {
+		metricType := m.Type
+		if metricType == "" {
+			metricType = metricTypeCount
+		}
+		info.WithLabelValues(
+			metricID(m),
+			strconv.FormatFloat(m.ParsedInterval.Seconds(), 'f', -1, 64),
+			metricType,
+			m.GroupBy,
+			jqlHash(m.JQL),
+		).Set(1)
+	}
+	return nil
+}
+
+// warnUnconfigured logs a warning if no metrics are configured. The exporter
+// keeps running so that health checks pass, jiravars_configured_metrics
+// allows alerting on it instead.
+func warnUnconfigured(log *logrus.Logger, metrics []MetricConfiguration) {
+	if len(metrics) == 0 {
+		log.Warn("No metrics configured, only the exporter's own metrics will be served")
+	}
+}
@@ -0,0 +1,56 @@
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupConfigInfo(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics := []MetricConfiguration{
+		{Name: "open_bugs", JQL: `project = TEST AND reporter = "secret.person"`, GroupBy: "assignee", ParsedInterval: 5 * time.Minute},
+		{Name: "backlog", JQL: "project = TEST", Type: metricTypeTimeSpent, ParsedInterval: 90 * time.Second},
+	}
+	require.NoError(t, setupConfigInfo(reg, metrics))
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP jiravars_configured_metrics Number of configured metrics
+# TYPE jiravars_configured_metrics gauge
+jiravars_configured_metrics 2
+# HELP jiravars_metric_info Configuration of a metric, always 1
+# TYPE jiravars_metric_info gauge
+jiravars_metric_info{groupby="",interval="90",jql_hash="`+jqlHash("project = TEST")+`",metric="backlog",type="timeSpent"} 1
+jiravars_metric_info{groupby="assignee",interval="300",jql_hash="`+jqlHash(metrics[0].JQL)+`",metric="open_bugs",type="count"} 1
+`)))
+}
+
+func TestNoMetricsConfigured(t *testing.T) {
+	log, hook := logtest.NewNullLogger()
+	cfg, err := LoadConfiguration(writeConfig(t, `
+baseURL: https://jira.example.com
+metrics: []
+`), false)
+	require.NoError(t, err)
+	reg := prometheus.NewRegistry()
+	require.NoError(t, SetupGauges(reg, reg, cfg.Metrics))
+	require.NoError(t, setupConfigInfo(reg, cfg.Metrics))
+	warnUnconfigured(log, cfg.Metrics)
+	require.Len(t, hook.AllEntries(), 1)
+	require.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP jiravars_configured_metrics Number of configured metrics
+# TYPE jiravars_configured_metrics gauge
+jiravars_configured_metrics 0
+`), "jiravars_configured_metrics"))
+
+	// The worker returns right away instead of blocking or failing.
+	NewScraper(log, cfg, &http.Client{}).Run(context.Background())
+}
@@ -0,0 +1,78 @@
+package exporter
+
+import (
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Bounds of the delay between retries after connection failures and of
+// the number of retries before falling back to the metric's interval.
+const (
+	connectionRetryBase     = time.Second
+	connectionRetryMax      = 30 * time.Second
+	connectionRetryAttempts = 10
+)
+
+// isTransientConnectionError reports whether the scrape failed before a
+// connection to Jira could be established for a reason that is likely to go
+// away soon, e.g. a DNS lookup that failed or a refused connection. Unknown
+// hosts are included as their DNS entries often show up only after the
+// exporter has been started. Errors after connecting like TLS failures or
+// HTTP status codes are considered permanent.
+func isTransientConnectionError(err error) bool {
+	var scrapeErr *scrapeError
+	if !errors.As(err, &scrapeErr) || scrapeErr.reason != errorReasonRequest {
+		return false
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsNotFound || dnsErr.IsTemporary || dnsErr.IsTimeout
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// connectionRetry computes exponentially growing, jittered delays for
+// retrying a scrape after connection failures so that metrics are populated
+// as soon as Jira becomes reachable instead of only after a full interval.
+// Retries stop after connectionRetryAttempts or once the delay would reach
+// the interval, from then on the scrape waits for the next tick.
+type connectionRetry struct {
+	base     time.Duration
+	max      time.Duration
+	interval time.Duration
+	attempts int
+}
+
+// newConnectionRetry returns retry delays for a metric scraped at the given
+// interval.
+func newConnectionRetry(interval time.Duration) *connectionRetry {
+	return &connectionRetry{base: connectionRetryBase, max: connectionRetryMax, interval: interval}
+}
+
+// next returns the delay before the next retry, picked randomly from the
+// upper half of the current backoff. It returns false if the scrape should
+// not be retried before the next tick.
+func (r *connectionRetry) next() (time.Duration, bool) {
+	if r.attempts >= connectionRetryAttempts {
+		return 0, false
+	}
+	d := r.base << uint(r.attempts)
+	if d > r.max || d <= 0 {
+		d = r.max
+	}
+	if d >= r.interval {
+		return 0, false
+	}
+	r.attempts++
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1)), true
+}
+
+// reset starts over with the base delay.
+func (r *connectionRetry) reset() {
+	r.attempts = 0
+}
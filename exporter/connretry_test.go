@@ -0,0 +1,101 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTransientConnectionError(t *testing.T) {
+	requestError := func(err error) error {
+		return &scrapeError{reason: errorReasonRequest, err: errors.Wrap(err, "failed to execute HTTP request")}
+	}
+	require.True(t, isTransientConnectionError(requestError(&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED})))
+	require.True(t, isTransientConnectionError(requestError(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "server misbehaving", Name: "jira", IsTemporary: true}})))
+	require.True(t, isTransientConnectionError(requestError(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "jira", IsNotFound: true}})))
+	require.False(t, isTransientConnectionError(requestError(&net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET})))
+	require.False(t, isTransientConnectionError(&scrapeError{reason: errorReasonStatus, statusCode: http.StatusServiceUnavailable}))
+	require.False(t, isTransientConnectionError(nil))
+}
+
+func TestConnectionRetry(t *testing.T) {
+	// Retries stop once the backoff reaches the interval.
+	r := newConnectionRetry(5 * time.Second)
+	for _, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		d, ok := r.next()
+		require.True(t, ok)
+		require.GreaterOrEqual(t, d, max/2)
+		require.LessOrEqual(t, d, max)
+	}
+	_, ok := r.next()
+	require.False(t, ok)
+	r.reset()
+	d, ok := r.next()
+	require.True(t, ok)
+	require.LessOrEqual(t, d, time.Second)
+
+	// Long intervals are retried at most every 30 seconds and only a
+	// limited number of times.
+	r = newConnectionRetry(time.Hour)
+	for i := 0; i < connectionRetryAttempts; i++ {
+		d, ok := r.next()
+		require.True(t, ok)
+		require.LessOrEqual(t, d, connectionRetryMax)
+	}
+	_, ok = r.next()
+	require.False(t, ok)
+
+	_, ok = newConnectionRetry(100 * time.Millisecond).next()
+	require.False(t, ok)
+}
+
+func TestRetryAfterDialFailure(t *testing.T) {
+	log, _ := logtest.NewNullLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"total": 5}`)
+		cancel()
+	}))
+	defer srv.Close()
+	var dials int32
+	dialer := &net.Dialer{}
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network string, addr string) (net.Conn, error) {
+			if atomic.AddInt32(&dials, 1) == 1 {
+				return nil, &net.OpError{Op: "dial", Net: network, Err: syscall.ECONNREFUSED}
+			}
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}}
+	cfg := &Configuration{
+		BaseURL: srv.URL,
+		Metrics: []MetricConfiguration{
+			{
+				Name:           "test",
+				Help:           "test",
+				JQL:            "project = TEST",
+				ParsedInterval: time.Hour,
+			},
+		},
+	}
+	require.NoError(t, SetupGauges(prometheus.NewRegistry(), prometheus.NewRegistry(), cfg.Metrics))
+	start := time.Now()
+	NewScraper(log, cfg, client).Run(ctx)
+
+	require.Less(t, time.Since(start), 5*time.Second)
+	require.Equal(t, int32(2), atomic.LoadInt32(&dials))
+	require.Equal(t, float64(5), testutil.ToFloat64(cfg.Metrics[0].Gauge))
+}
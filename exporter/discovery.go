@@ -0,0 +1,195 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultProjectRefreshInterval is the interval at which discovered projects
+// are fetched again if no refreshInterval has been configured.
+const defaultProjectRefreshInterval = time.Hour
+
+// projectLabel is the label holding the key of a discovered project.
+const projectLabel = "project"
+
+// projectDiscoveryConfiguration runs the JQL of a metric once per project
+// of the Jira instance, optionally limited to matching project categories.
+type projectDiscoveryConfiguration struct {
+	CategoryRegex         string        `yaml:"categoryRegex"`
+	RefreshInterval       string        `yaml:"refreshInterval"`
+	ParsedRefreshInterval time.Duration `yaml:"-"`
+
+	category *regexp.Regexp
+	cache    *projectCache
+}
+
+// validateProjectDiscovery checks the discovery settings of a metric. The
+// JQL is rendered with the key of every project as {{ .project }}, so
+// discovery can't be combined with settings that are exported as labels as
+// well. Only the totals of the searches are read, so settings that need
+// the issues themselves are rejected.
+func validateProjectDiscovery(m *MetricConfiguration) error {
+	d := m.DiscoverProjects
+	if d == nil {
+		return nil
+	}
+	if m.Type != "" && m.Type != metricTypeCount {
+		return errors.Errorf("discoverProjects is not supported for %s metrics", m.Type)
+	}
+	if m.GroupBy != "" {
+		return errors.New("discoverProjects and groupBy must not be used together")
+	}
+	if len(m.Variables) > 0 {
+		return errors.New("discoverProjects and variables must not be used together")
+	}
+	if m.Subtasks != "" && m.Subtasks != subtasksInclude {
+		return errors.Errorf("discoverProjects is not supported with subtasks: %s", m.Subtasks)
+	}
+	if m.QueryBudget != "" {
+		return errors.New("discoverProjects and queryBudget must not be used together")
+	}
+	if _, ok := m.Labels[projectLabel]; ok {
+		return errors.Errorf("discoverProjects conflicts with the label %s", projectLabel)
+	}
+	var err error
+	if d.category, err = regexp.Compile(d.CategoryRegex); err != nil {
+		return errors.Wrap(err, "invalid discoverProjects.categoryRegex")
+	}
+	d.ParsedRefreshInterval = defaultProjectRefreshInterval
+	if d.RefreshInterval != "" {
+		if d.ParsedRefreshInterval, err = time.ParseDuration(d.RefreshInterval); err != nil {
+			return errors.Wrap(err, "invalid discoverProjects.refreshInterval")
+		}
+		if d.ParsedRefreshInterval <= 0 {
+			return errors.New("discoverProjects.refreshInterval must be positive")
+		}
+	}
+	d.cache = &projectCache{refresh: d.ParsedRefreshInterval, now: time.Now}
+	return nil
+}
+
+// withProject returns a copy of the metric for the given project key.
+func withProject(m MetricConfiguration, key string) MetricConfiguration {
+	values := make(map[string]string, len(m.MatrixValues)+1)
+	for k, v := range m.MatrixValues {
+		values[k] = v
+	}
+	values[projectLabel] = key
+	m.MatrixValues = values
+	return m
+}
+
+// projectCache keeps the keys of the discovered projects until they have
+// to be refreshed.
+type projectCache struct {
+	mu      sync.Mutex
+	refresh time.Duration
+	now     func() time.Time
+	keys    []string
+	fetched time.Time
+}
+
+// project is an entry of Jira's project list.
+type project struct {
+	Key      string `json:"key"`
+	Category *struct {
+		Name string `json:"name"`
+	} `json:"projectCategory"`
+}
+
+// projectsURL returns the URL of Jira's project list which lives next to
+// the search API.
+func projectsURL(cfg *Configuration) string {
+	apiPath := cfg.APIPath
+	if apiPath == "" {
+		apiPath = defaultAPIPath
+	}
+	return fmt.Sprintf("%s%s", cfg.BaseURL, path.Join(path.Dir(apiPath), "project"))
+}
+
+// discoverProjects returns the keys of all projects of a matching category.
+// The list is cached for the refresh interval.
+func (s *endpointScraper) discoverProjects(ctx context.Context, d *projectDiscoveryConfiguration) ([]string, error) {
+	c := d.cache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.keys != nil && c.now().Sub(c.fetched) < c.refresh {
+		return c.keys, nil
+	}
+	var projects []project
+	if err := s.getJSON(ctx, projectsURL(s.cfg), &projects); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(projects))
+	for _, p := range projects {
+		category := ""
+		if p.Category != nil {
+			category = p.Category.Name
+		}
+		if d.category.MatchString(category) {
+			keys = append(keys, p.Key)
+		}
+	}
+	c.keys = keys
+	c.fetched = c.now()
+	return keys, nil
+}
+
+// scrapeDiscovered counts the issues matching the metric's JQL once per
+// discovered project. Projects that disappeared are no longer part of the
+// groups, so their series are removed by updateGauge. Projects Jira refuses
+// to count, e.g. because they can't be browsed, are recorded in the result
+// and skipped, unless that happens for all projects.
+func (s *endpointScraper) scrapeDiscovered(ctx context.Context, m MetricConfiguration) (scrapeResult, error) {
+	keys, err := s.discoverProjects(ctx, m.DiscoverProjects)
+	if err != nil {
+		return scrapeResult{}, err
+	}
+	result := scrapeResult{Groups: make(map[string]float64, len(keys))}
+	now := time.Now()
+	for _, key := range keys {
+		rendered, err := renderMetric(withProject(m, key), s.cfg.Variables, now)
+		if err != nil {
+			return result, &scrapeError{reason: errorReasonRequest, err: err}
+		}
+		pr, err := s.search(ctx, searchURL(s.cfg, rendered, 0), nil)
+		result.Pages++
+		if isProjectError(err) {
+			if result.ProjectErrors == nil {
+				result.ProjectErrors = make(map[string]error)
+			}
+			result.ProjectErrors[key] = err
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+		result.Total += pr.Total
+		result.Groups[key] = float64(pr.Total)
+	}
+	if len(keys) > 0 && len(result.ProjectErrors) == len(keys) {
+		return result, result.ProjectErrors[keys[len(keys)-1]]
+	}
+	return result, nil
+}
+
+// isProjectError reports whether the error is caused by the request for a
+// single project rather than by Jira being unavailable.
+func isProjectError(err error) bool {
+	var scrapeErr *scrapeError
+	if !errors.As(err, &scrapeErr) || scrapeErr.reason != errorReasonStatus {
+		return false
+	}
+	switch scrapeErr.statusCode {
+	case http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectDiscovery(t *testing.T) {
+	projects := atomic.Value{}
+	projects.Store(`[
+		{"key": "API", "projectCategory": {"name": "Engineering Backend"}},
+		{"key": "WEB", "projectCategory": {"name": "Engineering Frontend"}},
+		{"key": "HR", "projectCategory": {"name": "People"}},
+		{"key": "MISC"}
+	]`)
+	var projectRequests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/project":
+			atomic.AddInt32(&projectRequests, 1)
+			fmt.Fprint(w, projects.Load())
+		case "/rest/api/2/search":
+			fmt.Fprintf(w, `{"total": %d}`, len(r.URL.Query().Get("jql")))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	cfg, err := LoadConfiguration(writeConfig(t, fmt.Sprintf(`
+baseURL: %s
+metrics:
+  - name: open_issues
+    help: Open issues per project
+    jql: project = {{ .project }}
+    discoverProjects:
+      categoryRegex: ^Engineering
+      refreshInterval: 10m
+`, srv.URL)), false)
+	require.NoError(t, err)
+	reg := prometheus.NewRegistry()
+	require.NoError(t, SetupGauges(reg, reg, cfg.Metrics))
+	m := cfg.Metrics[0]
+	now := time.Now()
+	m.DiscoverProjects.cache.now = func() time.Time { return now }
+
+	s := newEndpointScraper(cfg, &http.Client{})
+	result, err := s.scrape(context.Background(), m)
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"API": float64(len("project = API")), "WEB": float64(len("project = WEB"))}, result.Groups)
+	groups := updateGauge(m, result, nil)
+
+	// The project list is cached until the refresh interval has passed.
+	projects.Store(`[{"key": "API", "projectCategory": {"name": "Engineering Backend"}}]`)
+	_, err = s.scrape(context.Background(), m)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&projectRequests))
+
+	now = now.Add(10 * time.Minute)
+	result, err = s.scrape(context.Background(), m)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&projectRequests))
+	updateGauge(m, result, groups)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP jira_open_issues Open issues per project
+# TYPE jira_open_issues gauge
+jira_open_issues{project="API"} 13
+`), "jira_open_issues"))
+}
+func TestProjectDiscoveryFailures(t *testing.T) {
+	forbidden := map[string]bool{"WEB": true}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/project":
+			fmt.Fprint(w, `[{"key": "API"}, {"key": "WEB"}]`)
+		case "/rest/api/2/search":
+			if forbidden[strings.TrimPrefix(r.URL.Query().Get("jql"), "project = ")] {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprint(w, `{"total": 3}`)
+		}
+	}))
+	defer srv.Close()
+
+	cfg, err := LoadConfiguration(writeConfig(t, fmt.Sprintf(`
+baseURL: %s
+metrics:
+  - name: open_issues
+    jql: project = {{ .project }}
+    discoverProjects: {}
+`, srv.URL)), false)
+	require.NoError(t, err)
+	s := newEndpointScraper(cfg, &http.Client{})
+
+	// A project that can't be counted doesn't keep the others from being
+	// counted.
+	result, err := s.scrape(context.Background(), cfg.Metrics[0])
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"API": 3}, result.Groups)
+	require.Len(t, result.ProjectErrors, 1)
+	require.Error(t, result.ProjectErrors["WEB"])
+
+	// If no project can be counted, the scrape fails.
+	forbidden["API"] = true
+	_, err = s.scrape(context.Background(), cfg.Metrics[0])
+	require.Error(t, err)
+
+	// JQL that can't be rendered for a project fails like any other request.
+	m := MetricConfiguration{Name: "broken", JQL: "project = {{ .missing }}", DiscoverProjects: &projectDiscoveryConfiguration{}}
+	require.NoError(t, validateProjectDiscovery(&m))
+	_, err = s.scrape(context.Background(), m)
+	var scrapeErr *scrapeError
+	require.True(t, errors.As(err, &scrapeErr))
+	require.Equal(t, errorReasonRequest, scrapeErr.reason)
+}
+
+func TestValidateProjectDiscovery(t *testing.T) {
+	for name, metric := range map[string]string{
+		"invalid-regex":    "discoverProjects: {categoryRegex: '('}",
+		"with-groupBy":     "discoverProjects: {}\n    groupBy: priority",
+		"invalid-refresh":  "discoverProjects: {refreshInterval: often}",
+		"zero-refresh":     "discoverProjects: {refreshInterval: 0s}",
+		"negative-refresh": "discoverProjects: {refreshInterval: -1m}",
+		"project-label":    "discoverProjects: {}\n    labels: {project: x}",
+		"subtasks-exclude": "discoverProjects: {}\n    subtasks: exclude",
+		"subtasks-sep":     "discoverProjects: {}\n    subtasks: separate",
+		"query-budget":     "discoverProjects: {}\n    queryBudget: 10s",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadConfiguration(writeConfig(t, "metrics:\n  - name: test\n    jql: project = {{ .project }}\n    "+metric+"\n"), false)
+			require.Error(t, err)
+		})
+	}
+	require.Equal(t, "https://jira.example.com/gateway/api/2/project", projectsURL(&Configuration{BaseURL: "https://jira.example.com", APIPath: "/gateway/api/2/search"}))
+}
@@ -0,0 +1,46 @@
+package exporter
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// validateDistinctCount checks the settings of a metric counting the
+// distinct values of its groupBy field.
+func validateDistinctCount(m MetricConfiguration) error {
+	if !m.DistinctCount {
+		return nil
+	}
+	if m.GroupBy == "" {
+		return errors.New("distinctCount requires groupBy")
+	}
+	if m.Type != "" && m.Type != metricTypeCount && m.Type != metricTypeSprint {
+		return errors.Errorf("distinctCount is not supported for %s metrics", m.Type)
+	}
+	if m.TopN > 0 || m.WeightedCount != nil {
+		return errors.New("distinctCount must not be used together with topN or weightedCount")
+	}
+	return nil
+}
+
+// scrapeDistinct counts the distinct non-empty values of the groupBy field
+// across all issues matching the metric's JQL. Values rejected by the value
+// filter are not counted.
+func (s *endpointScraper) scrapeDistinct(ctx context.Context, m MetricConfiguration) (scrapeResult, error) {
+	g, err := lookupGrouper(m.GroupBy)
+	if err != nil {
+		return scrapeResult{}, &scrapeError{reason: errorReasonRequest, err: err}
+	}
+	values := make(map[string]struct{})
+	result, err := s.fetchIssues(ctx, m, func(i issue) error {
+		for _, v := range normalizeValues(m.Normalize, g.values(i, m)) {
+			if m.valueFilter == nil || m.valueFilter.allowed(v) {
+				values[v] = struct{}{}
+			}
+		}
+		return nil
+	})
+	result.Value = float64(len(values))
+	return result, err
+}
@@ -0,0 +1,69 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistinctCount(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "labels", r.URL.Query().Get("fields"))
+		fmt.Fprint(w, `{"total": 4, "issues": [
+			{"key": "A-1", "fields": {"labels": ["backend", "urgent"]}},
+			{"key": "A-2", "fields": {"labels": ["Backend "]}},
+			{"key": "A-3", "fields": {"labels": ["frontend"]}},
+			{"key": "A-4", "fields": {"labels": []}}
+		]}`)
+	}))
+	defer srv.Close()
+	cfg, err := LoadConfiguration(writeConfig(t, fmt.Sprintf(`
+baseURL: %s
+metrics:
+  - name: labels_in_use
+    help: Distinct labels of open issues
+    jql: project = A
+    groupBy: labels
+    normalize: [trim, lowercase]
+    distinctCount: true
+`, srv.URL)), false)
+	require.NoError(t, err)
+	reg := prometheus.NewRegistry()
+	require.NoError(t, SetupGauges(reg, reg, cfg.Metrics))
+	m := cfg.Metrics[0]
+
+	s := newEndpointScraper(cfg, &http.Client{})
+	result, err := s.scrape(context.Background(), m)
+	require.NoError(t, err)
+	updateGauge(m, result, nil)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP jira_labels_in_use Distinct labels of open issues
+# TYPE jira_labels_in_use gauge
+jira_labels_in_use 3
+`), "jira_labels_in_use"))
+}
+
+func TestValidateDistinctCount(t *testing.T) {
+	require.NoError(t, validateDistinctCount(MetricConfiguration{}))
+	require.Error(t, validateDistinctCount(MetricConfiguration{DistinctCount: true}))
+	require.NoError(t, validateDistinctCount(MetricConfiguration{DistinctCount: true, GroupBy: "labels"}))
+	require.Error(t, validateDistinctCount(MetricConfiguration{DistinctCount: true, GroupBy: "labels", TopN: 3}))
+	require.Error(t, validateDistinctCount(MetricConfiguration{DistinctCount: true, GroupBy: "labels", Type: metricTypeTimeSpent}))
+
+	// A single series per metric doesn't need to be bounded by topN.
+	_, err := LoadConfiguration(writeConfig(t, `
+metrics:
+  - name: assignees
+    jql: project = A
+    groupBy: assignee
+    distinctCount: true
+`), false)
+	require.NoError(t, err)
+}
@@ -0,0 +1,122 @@
+package exporter
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Types of the scrape duration metric. They trade accuracy against cost and
+// flexibility:
+//
+//   - histogram counts the durations in fixed buckets. Quantiles can be
+//     computed and aggregated across metrics and instances in PromQL, but
+//     only as accurately as the buckets allow.
+//   - summary computes the configured quantiles in the exporter. They are
+//     accurate, but cost memory and CPU per metric and can't be aggregated.
+//   - nativeHistogram uses exponential buckets with a high resolution at a
+//     low cost. Prometheus has to scrape using protobuf with native
+//     histograms enabled though, the text format only shows the count and
+//     the sum.
+const (
+	scrapeDurationHistogram       = "histogram"
+	scrapeDurationSummary         = "summary"
+	scrapeDurationNativeHistogram = "nativeHistogram"
+)
+
+var defaultDurationObjectives = []float64{0.5, 0.95, 0.99}
+
+// scrapeDurationConfiguration selects how the duration of scrapes is
+// exported.
+type scrapeDurationConfiguration struct {
+	// Type is either "histogram" (the default), "summary" or
+	// "nativeHistogram".
+	Type string `yaml:"type"`
+	// Objectives are the quantiles computed by summaries.
+	Objectives []float64 `yaml:"objectives"`
+}
+
+func validateScrapeDuration(cfg *scrapeDurationConfiguration) error {
+	switch cfg.Type {
+	case "":
+		cfg.Type = scrapeDurationHistogram
+	case scrapeDurationHistogram, scrapeDurationSummary, scrapeDurationNativeHistogram:
+	default:
+		return errors.Errorf("unsupported scrapeDuration.type %q", cfg.Type)
+	}
+	if len(cfg.Objectives) > 0 && cfg.Type != scrapeDurationSummary {
+		return errors.New("scrapeDuration.objectives require type summary")
+	}
+	for _, q := range cfg.Objectives {
+		if q <= 0 || q >= 1 {
+			return errors.Errorf("invalid scrapeDuration.objectives entry %v, must be between 0 and 1", q)
+		}
+	}
+	return nil
+}
+
+// objectives returns the quantiles of a summary along with their allowed
+// error, which is a tenth of the distance to the closer end.
+func (cfg scrapeDurationConfiguration) objectives() map[float64]float64 {
+	quantiles := cfg.Objectives
+	if len(quantiles) == 0 {
+		quantiles = defaultDurationObjectives
+	}
+	result := make(map[float64]float64, len(quantiles))
+	for _, q := range quantiles {
+		result[q] = (0.5 - abs(0.5-q)) / 10
+	}
+	return result
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
+
+// setupScrapeDurations registers jira_scrape_duration_seconds{metric} of the
+// configured type with telemetry.
+func setupScrapeDurations(telemetry prometheus.Registerer, cfg scrapeDurationConfiguration, metrics []MetricConfiguration) error {
+	const (
+		name = "jira_scrape_duration_seconds"
+		help = "Duration of scrapes including all requests to Jira, per metric"
+	)
+	var vec prometheus.ObserverVec
+	switch cfg.Type {
+	case scrapeDurationSummary:
+		vec = prometheus.NewSummaryVec(prometheus.SummaryOpts{
+			Name:       name,
+			Help:       help,
+			Objectives: cfg.objectives(),
+		}, []string{"metric"})
+	case scrapeDurationNativeHistogram:
+		vec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:                        name,
+			Help:                        help,
+			NativeHistogramBucketFactor: 1.1,
+		}, []string{"metric"})
+	default:
+		vec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    name,
+			Help:    help,
+			Buckets: []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"metric"})
+	}
+	if err := telemetry.Register(vec); err != nil {
+		return err
+	}
+	for i := range metrics {
+		metrics[i].Duration = vec.WithLabelValues(metricID(metrics[i]))
+	}
+	return nil
+}
+
+// observeDuration records the duration of a scrape of the given metric.
+func observeDuration(m MetricConfiguration, d time.Duration) {
+	if m.Duration != nil {
+		m.Duration.Observe(d.Seconds())
+	}
+}
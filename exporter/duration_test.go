@@ -0,0 +1,75 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrapeDurationSummary(t *testing.T) {
+	log, _ := logtest.NewNullLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"total": 1}`)
+		cancel()
+	}))
+	defer srv.Close()
+	cfg, err := LoadConfiguration(writeConfig(t, fmt.Sprintf(`
+baseURL: %s
+scrapeDuration:
+  type: summary
+  objectives: [0.5, 0.99]
+metrics:
+  - name: test
+    help: test
+    jql: project = TEST
+`, srv.URL)), false)
+	require.NoError(t, err)
+	reg := prometheus.NewRegistry()
+	require.NoError(t, SetupGauges(reg, reg, cfg.Metrics))
+	require.NoError(t, setupScrapeDurations(reg, cfg.ScrapeDuration, cfg.Metrics))
+	NewScraper(log, cfg, &http.Client{}).Run(ctx)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	var found bool
+	for _, family := range families {
+		if family.GetName() != "jira_scrape_duration_seconds" {
+			continue
+		}
+		found = true
+		summary := family.GetMetric()[0].GetSummary()
+		require.NotNil(t, summary)
+		require.Equal(t, uint64(1), summary.GetSampleCount())
+		require.Len(t, summary.GetQuantile(), 2)
+	}
+	require.True(t, found)
+}
+
+func TestValidateScrapeDuration(t *testing.T) {
+	cfg := scrapeDurationConfiguration{}
+	require.NoError(t, validateScrapeDuration(&cfg))
+	require.Equal(t, scrapeDurationHistogram, cfg.Type)
+	require.Error(t, validateScrapeDuration(&scrapeDurationConfiguration{Type: "gauge"}))
+	require.Error(t, validateScrapeDuration(&scrapeDurationConfiguration{Type: scrapeDurationHistogram, Objectives: []float64{0.5}}))
+	require.Error(t, validateScrapeDuration(&scrapeDurationConfiguration{Type: scrapeDurationSummary, Objectives: []float64{1}}))
+
+	objectives := scrapeDurationConfiguration{}.objectives()
+	require.Equal(t, map[float64]float64{0.5: 0.05, 0.95: 0.005, 0.99: 0.001}, roundObjectives(objectives))
+}
+
+// roundObjectives rounds the allowed errors to avoid floating point noise.
+func roundObjectives(objectives map[float64]float64) map[float64]float64 {
+	result := make(map[float64]float64, len(objectives))
+	for q, e := range objectives {
+		result[q] = float64(int(e*10000+0.5)) / 10000
+	}
+	return result
+}
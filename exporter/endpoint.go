@@ -0,0 +1,201 @@
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// endpointConfiguration is one of several Jira base URLs that are tried in
+// order, e.g. a read replica followed by the primary instance.
+type endpointConfiguration struct {
+	// Name is exported as endpoint label and defaults to the host of the
+	// base URL.
+	Name    string `yaml:"name"`
+	BaseURL string `yaml:"baseURL"`
+	// Login and Password default to the top-level credentials.
+	Login    string `yaml:"login"`
+	Password string `yaml:"password"`
+}
+
+// normalizeBaseURL checks that the given base URL is an absolute HTTP(S)
+// URL and strips trailing slashes, so that API paths can be appended to it
+// while keeping a context path like /jira.
+func normalizeBaseURL(baseURL string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", errors.Errorf("%q is not an http or https URL", baseURL)
+	}
+	if u.Host == "" {
+		return "", errors.Errorf("%q has no host", baseURL)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", errors.Errorf("%q must not contain a query or fragment", baseURL)
+	}
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = strings.TrimRight(u.RawPath, "/")
+	return u.String(), nil
+}
+
+// validateEndpoints normalizes the base URLs, checks the configured endpoints
+// and fills in their default names.
+func validateEndpoints(cfg *Configuration) error {
+	if cfg.BaseURL != "" {
+		var err error
+		if cfg.BaseURL, err = normalizeBaseURL(cfg.BaseURL); err != nil {
+			return errors.Wrap(err, "invalid baseURL")
+		}
+	}
+	if len(cfg.Endpoints) == 0 {
+		return nil
+	}
+	if cfg.BaseURL != "" {
+		return errors.New("baseURL and endpoints must not be used together")
+	}
+	names := make(map[string]struct{}, len(cfg.Endpoints))
+	for i := range cfg.Endpoints {
+		e := &cfg.Endpoints[i]
+		if e.BaseURL == "" {
+			return errors.Errorf("endpoint %d has no baseURL", i)
+		}
+		var err error
+		if e.BaseURL, err = normalizeBaseURL(e.BaseURL); err != nil {
+			return errors.Wrapf(err, "invalid baseURL of endpoint %d", i)
+		}
+		if e.Name == "" {
+			e.Name = instanceLabel(e.BaseURL)
+		}
+		if _, ok := names[e.Name]; ok {
+			return errors.Errorf("duplicate endpoint name %q, please set distinct names", e.Name)
+		}
+		names[e.Name] = struct{}{}
+	}
+	return nil
+}
+
+// endpointConfigs returns one copy of the configuration per endpoint with
+// the base URL and credentials of that endpoint. Without endpoints the
+// configuration itself is the only endpoint.
+func endpointConfigs(cfg *Configuration) []*Configuration {
+	if len(cfg.Endpoints) == 0 {
+		return []*Configuration{cfg}
+	}
+	result := make([]*Configuration, 0, len(cfg.Endpoints))
+	for _, e := range cfg.Endpoints {
+		c := *cfg
+		c.BaseURL = e.BaseURL
+		if e.Login != "" {
+			c.Login = e.Login
+		}
+		if e.Password != "" {
+			c.Password = e.Password
+		}
+		result = append(result, &c)
+	}
+	return result
+}
+
+// endpointName returns the label value of the given endpoint configuration
+// as returned by endpointConfigs.
+func endpointName(cfg *Configuration, idx int) string {
+	if len(cfg.Endpoints) == 0 {
+		return instanceLabel(cfg.BaseURL)
+	}
+	return cfg.Endpoints[idx].Name
+}
+
+// failoverScraper scrapes a metric from the first endpoint that answers.
+// Endpoints whose circuit breaker is open are skipped.
+type failoverScraper struct {
+	names    []string
+	scrapers []*endpointScraper
+	breakers []*circuitBreaker
+}
+
+func newFailoverScraper(log *logrus.Logger, cfg *Configuration, client *http.Client) *failoverScraper {
+	f := &failoverScraper{breakers: cfg.circuitBreakers}
+	for i, c := range endpointConfigs(cfg) {
+		f.names = append(f.names, endpointName(cfg, i))
+		f.scrapers = append(f.scrapers, newEndpointScraper(c, client))
+		if cfg.circuitBreakers == nil {
+			f.breakers = append(f.breakers, newCircuitBreaker(log, endpointName(cfg, i), cfg.CircuitBreakerConfig, nil))
+		}
+	}
+	return f
+}
+
+// available reports whether the circuit breaker of at least one endpoint
+// lets requests through.
+func (f *failoverScraper) available() bool {
+	for _, b := range f.breakers {
+		if !b.suspended() {
+			return true
+		}
+	}
+	return false
+}
+
+// scrape tries all endpoints in order until one of them does not fail with
+// an error that warrants a failover. It returns the name of the endpoint
+// that served the result.
+func (f *failoverScraper) scrape(ctx context.Context, m MetricConfiguration) (scrapeResult, string, error) {
+	var result scrapeResult
+	err := errCircuitOpen
+	for i, s := range f.scrapers {
+		if !f.breakers[i].allow() {
+			continue
+		}
+		result, err = scrapeEndpoint(ctx, f.breakers[i], s, m)
+		if err == nil || !shouldFailover(err) || ctx.Err() != nil {
+			return result, f.names[i], err
+		}
+	}
+	return result, "", err
+}
+
+// scrapeEndpoint scrapes a single endpoint and records the outcome in its
+// circuit breaker. A panicking scrape is recorded as aborted so that it
+// doesn't keep the probe slot of the breaker forever.
+func scrapeEndpoint(ctx context.Context, b *circuitBreaker, s *endpointScraper, m MetricConfiguration) (result scrapeResult, err error) {
+	err = errRequestAborted
+	defer func() {
+		b.record(err)
+	}()
+	return s.scrape(ctx, m)
+}
+
+// shouldFailover reports whether the error indicates that the endpoint is
+// unavailable rather than that the request itself is wrong.
+func shouldFailover(err error) bool {
+	var scrapeErr *scrapeError
+	if !errors.As(err, &scrapeErr) {
+		return false
+	}
+	switch scrapeErr.reason {
+	case errorReasonRequest:
+		return true
+	case errorReasonStatus:
+		return scrapeErr.statusCode >= 500
+	}
+	return false
+}
+
+// setEndpoint replaces the series recording which endpoint served the last
+// successful scrape of the given metric. It returns the new endpoint.
+func setEndpoint(m MetricConfiguration, previous string, endpoint string) string {
+	if m.Endpoint == nil || previous == endpoint {
+		return endpoint
+	}
+	if previous != "" {
+		m.Endpoint.DeleteLabelValues(metricID(m), previous)
+	}
+	m.Endpoint.WithLabelValues(metricID(m), endpoint).Set(1)
+	return endpoint
+}
@@ -0,0 +1,143 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricTypeEpicProgress = "epicProgress"
+
+// defaultMaxEpics is the number of epics considered per scrape if no
+// maxEpics has been configured.
+const defaultMaxEpics = 50
+
+// epicLabel and statusCategoryLabel are the labels of epicProgress metrics.
+const (
+	epicLabel           = "epic"
+	statusCategoryLabel = "status_category"
+)
+
+// groupSeparator joins the label values of metrics with more than one
+// variable label into a single group. It can't be part of a valid label
+// value.
+const groupSeparator = "\xff"
+
+// splitGroup returns the label values of the given group.
+func splitGroup(group string) []string {
+	return strings.Split(group, groupSeparator)
+}
+
+func validateEpicMetric(m *MetricConfiguration) error {
+	if m.GroupBy != "" || len(m.Aggregates) > 0 {
+		return errors.New("groupBy and aggregates are not supported for epicProgress metrics")
+	}
+	if m.MaxEpics < 0 {
+		return errors.New("maxEpics must not be negative")
+	}
+	if m.MaxEpics == 0 {
+		m.MaxEpics = defaultMaxEpics
+	}
+	return nil
+}
+
+// setupEpicGauges registers jira_<name>{epic, status_category} for the given
+// epicProgress metric.
+func setupEpicGauges(registry prometheus.Registerer, m *MetricConfiguration, opts prometheus.GaugeOpts) error {
+	m.GaugeVec = prometheus.NewGaugeVec(opts, []string{epicLabel, statusCategoryLabel})
+	return registry.Register(m.GaugeVec)
+}
+
+func sendEpicStatsD(client *statsdClient, m MetricConfiguration, result scrapeResult) {
+	for group, count := range result.Groups {
+		values := splitGroup(group)
+		tags := make(map[string]string, len(m.Labels)+2)
+		for k, v := range m.Labels {
+			tags[k] = v
+		}
+		tags[epicLabel] = values[0]
+		tags[statusCategoryLabel] = values[1]
+		client.gauge(m.Name, count, tags)
+	}
+}
+
+// epicsURL returns the URL of the search request for the epics of the given
+// metric starting at startAt. Only the keys are needed.
+func epicsURL(cfg *Configuration, m MetricConfiguration, startAt int) string {
+	params := url.Values{}
+	params.Set("jql", m.JQL)
+	params.Set("fields", "key")
+	params.Set("maxResults", strconv.Itoa(m.MaxEpics-startAt))
+	if startAt > 0 {
+		params.Set("startAt", strconv.Itoa(startAt))
+	}
+	return apiURL(cfg, params)
+}
+
+// childrenJQL returns the JQL of the issues belonging to the given epics.
+// Older Jira versions link epics using the custom field configured as
+// epicField, newer ones use the parent.
+func childrenJQL(m MetricConfiguration, epics []string) string {
+	field := "parent"
+	if m.EpicField != "" {
+		field = fmt.Sprintf("cf[%s]", strings.TrimPrefix(m.EpicField, customFieldPrefix))
+	}
+	return fmt.Sprintf("%s in (%s)", field, strings.Join(epics, ", "))
+}
+
+// scrapeEpicProgress counts the child issues of every epic matching the
+// metric's JQL per status category. Only the first maxEpics epics are
+// considered, the number of ignored ones is returned as SkippedEpics.
+func (s *endpointScraper) scrapeEpicProgress(ctx context.Context, m MetricConfiguration) (scrapeResult, error) {
+	var epics []string
+	var total uint64
+	pages := 0
+	for len(epics) < m.MaxEpics {
+		pr, err := s.search(ctx, epicsURL(s.cfg, m, len(epics)), func(i issue) error {
+			epics = append(epics, i.Key)
+			return nil
+		})
+		if err != nil {
+			return scrapeResult{Pages: pages}, err
+		}
+		pages++
+		total = pr.Total
+		if pr.Issues == 0 || uint64(len(epics)) >= pr.Total {
+			break
+		}
+	}
+	if len(epics) > m.MaxEpics {
+		epics = epics[:m.MaxEpics]
+	}
+	groups := make(map[string]float64, len(epics)*len(statusCategoryKeys))
+	for _, epic := range epics {
+		for _, category := range statusCategoryKeys {
+			groups[epic+groupSeparator+category] = 0
+		}
+	}
+	if len(epics) == 0 {
+		return scrapeResult{Groups: groups, Pages: pages}, nil
+	}
+	children := m
+	children.JQL = childrenJQL(m, epics)
+	result, err := s.fetchIssues(ctx, children, func(i issue) error {
+		categories := statusCategoryValues(i, m)
+		for _, epic := range epicValues(i, m) {
+			for _, category := range categories {
+				groups[epic+groupSeparator+category]++
+			}
+		}
+		return nil
+	})
+	result.Pages += pages
+	result.Groups = groups
+	if total > uint64(len(epics)) {
+		result.SkippedEpics = total - uint64(len(epics))
+	}
+	return result, err
+}
@@ -0,0 +1,77 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEpicProgress(t *testing.T) {
+	var childQueries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch q.Get("jql") {
+		case "issuetype = Epic":
+			require.Equal(t, "key", q.Get("fields"))
+			require.Equal(t, "2", q.Get("maxResults"))
+			fmt.Fprint(w, `{"total": 3, "issues": [{"key": "PROJ-1"}, {"key": "PROJ-2"}]}`)
+		case "parent in (PROJ-1, PROJ-2)":
+			childQueries = append(childQueries, q.Get("jql"))
+			require.Equal(t, "status,parent", q.Get("fields"))
+			fmt.Fprint(w, `{"total": 3, "issues": [
+				{"key": "PROJ-10", "fields": {"parent": {"key": "PROJ-1"}, "status": {"statusCategory": {"key": "done"}}}},
+				{"key": "PROJ-11", "fields": {"parent": {"key": "PROJ-1"}, "status": {"statusCategory": {"key": "done"}}}},
+				{"key": "PROJ-12", "fields": {"parent": {"key": "PROJ-1"}, "status": {"statusCategory": {"key": "indeterminate"}}}}
+			]}`)
+		default:
+			t.Errorf("unexpected JQL %q", q.Get("jql"))
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	cfg, err := LoadConfiguration(writeConfig(t, fmt.Sprintf(`
+baseURL: %s
+metrics:
+  - name: epic_child_issues
+    help: Child issues per epic and status category
+    type: epicProgress
+    jql: issuetype = Epic
+    maxEpics: 2
+`, srv.URL)), false)
+	require.NoError(t, err)
+	reg := prometheus.NewRegistry()
+	require.NoError(t, SetupGauges(reg, reg, cfg.Metrics))
+	m := cfg.Metrics[0]
+
+	s := newEndpointScraper(cfg, &http.Client{})
+	result, err := s.scrape(context.Background(), m)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), result.SkippedEpics)
+	require.Equal(t, 2, result.Pages)
+	require.Len(t, childQueries, 1)
+	updateGauge(m, result, nil)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP jira_epic_child_issues Child issues per epic and status category
+# TYPE jira_epic_child_issues gauge
+jira_epic_child_issues{epic="PROJ-1",status_category="done"} 2
+jira_epic_child_issues{epic="PROJ-1",status_category="inprogress"} 1
+jira_epic_child_issues{epic="PROJ-1",status_category="todo"} 0
+jira_epic_child_issues{epic="PROJ-2",status_category="done"} 0
+jira_epic_child_issues{epic="PROJ-2",status_category="inprogress"} 0
+jira_epic_child_issues{epic="PROJ-2",status_category="todo"} 0
+`), "jira_epic_child_issues"))
+}
+
+func TestChildrenJQL(t *testing.T) {
+	epics := []string{"PROJ-1", "PROJ-2"}
+	require.Equal(t, "parent in (PROJ-1, PROJ-2)", childrenJQL(MetricConfiguration{}, epics))
+	require.Equal(t, "cf[10014] in (PROJ-1, PROJ-2)", childrenJQL(MetricConfiguration{EpicField: "customfield_10014"}, epics))
+}
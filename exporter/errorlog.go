@@ -0,0 +1,65 @@
+package exporter
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultErrorLogInterval is the cadence of summaries of repeated errors if
+// no errorLogInterval has been configured.
+const defaultErrorLogInterval = 5 * time.Minute
+
+// errorLogLimiter deduplicates the error logs of a single worker. The first
+// occurrence of an error is logged right away, identical errors following it
+// are only summarized once per interval. It is not safe for concurrent use.
+type errorLogLimiter struct {
+	interval time.Duration
+	now      func() time.Time
+
+	lastError  string
+	lastLogged time.Time
+	// repeated counts the identical errors since the last log entry.
+	repeated int
+	// failures counts all consecutive failures.
+	failures int
+}
+
+func newErrorLogLimiter(interval time.Duration) *errorLogLimiter {
+	if interval <= 0 {
+		interval = defaultErrorLogInterval
+	}
+	return &errorLogLimiter{interval: interval, now: time.Now}
+}
+
+// failure records a failed scrape and logs it unless it is a repetition of
+// the previous error that has been logged less than an interval ago.
+func (l *errorLogLimiter) failure(log logrus.FieldLogger, err error, msg string) {
+	l.failures++
+	now := l.now()
+	if err.Error() != l.lastError {
+		l.lastError = err.Error()
+		l.lastLogged = now
+		l.repeated = 0
+		log.WithError(err).Error(msg)
+		return
+	}
+	l.repeated++
+	if now.Sub(l.lastLogged) < l.interval {
+		return
+	}
+	log.WithError(err).Errorf("%s (error repeated %d times in the last %s)", msg, l.repeated, now.Sub(l.lastLogged).Round(time.Second))
+	l.lastLogged = now
+	l.repeated = 0
+}
+
+// success records a successful scrape and logs the recovery if it followed
+// any failures.
+func (l *errorLogLimiter) success(log logrus.FieldLogger, msg string) {
+	if l.failures > 0 {
+		log.Infof("%s (recovered after %d failures)", msg, l.failures)
+	}
+	l.failures = 0
+	l.repeated = 0
+	l.lastError = ""
+}
@@ -0,0 +1,43 @@
+package exporter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorLogLimiter(t *testing.T) {
+	log, hook := logtest.NewNullLogger()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newErrorLogLimiter(5 * time.Minute)
+	l.now = func() time.Time { return now }
+	failed := errors.New("request failed")
+
+	// Only the first of several identical errors is logged.
+	for i := 0; i < 10; i++ {
+		l.failure(log, failed, "Failed to scrape test")
+		now = now.Add(time.Minute)
+	}
+	require.Len(t, hook.AllEntries(), 2)
+	require.Equal(t, "Failed to scrape test", hook.AllEntries()[0].Message)
+	require.Equal(t, "Failed to scrape test (error repeated 5 times in the last 5m0s)", hook.AllEntries()[1].Message)
+
+	// A different error is logged right away.
+	l.failure(log, errors.New("decode failed"), "Failed to scrape test")
+	require.Len(t, hook.AllEntries(), 3)
+
+	l.success(log, "Completed test")
+	require.Len(t, hook.AllEntries(), 4)
+	require.Equal(t, logrus.InfoLevel, hook.LastEntry().Level)
+	require.Equal(t, "Completed test (recovered after 11 failures)", hook.LastEntry().Message)
+
+	// Without failures nothing is logged.
+	l.success(log, "Completed test")
+	require.Len(t, hook.AllEntries(), 4)
+	l.failure(log, failed, "Failed to scrape test")
+	require.Len(t, hook.AllEntries(), 5)
+}
@@ -0,0 +1,68 @@
+package exporter_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"github.com/zerok/jiravars/exporter"
+)
+
+func Example() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	jira := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"total": 42}`)
+		// Stop after the first scrape.
+		cancel()
+	}))
+	defer jira.Close()
+
+	dir, err := os.MkdirTemp("", "jiravars")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.yml")
+	config := fmt.Sprintf(`
+baseURL: %s
+login: me
+password: secret
+metrics:
+  - name: open_bugs
+    help: Open bugs
+    jql: type = Bug AND resolution IS EMPTY
+    interval: 1h
+`, jira.URL)
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		panic(err)
+	}
+
+	cfg, err := exporter.LoadConfiguration(path, false)
+	if err != nil {
+		panic(err)
+	}
+	log := logrus.New()
+	log.SetLevel(logrus.WarnLevel)
+	registry := prometheus.NewRegistry()
+	if err := exporter.Setup(log, registry, registry, cfg); err != nil {
+		panic(err)
+	}
+	exporter.NewScraper(log, cfg, http.DefaultClient).Run(ctx)
+
+	families, err := registry.Gather()
+	if err != nil {
+		panic(err)
+	}
+	for _, f := range families {
+		if f.GetName() == "jira_open_bugs" {
+			fmt.Println(f.GetMetric()[0].GetGauge().GetValue())
+		}
+	}
+	// Output: 42
+}
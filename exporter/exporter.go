@@ -0,0 +1,2021 @@
+package exporter
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// MetricConfiguration describes a single metric and, once set up, holds its
+// gauges.
+type MetricConfiguration struct {
+	Name    string `yaml:"name"`
+	Help    string `yaml:"help"`
+	JQL     string `yaml:"jql"`
+	JQLFile string `yaml:"jqlFile"`
+	// Enabled can be set to false to neither register nor scrape the
+	// metric without removing it from the configuration.
+	Enabled *bool `yaml:"enabled"`
+	// SkipValidation excludes the metric from --validate-queries, e.g. for
+	// JQL functions Jira's validation doesn't know about.
+	SkipValidation bool   `yaml:"skipValidation"`
+	GroupBy        string `yaml:"groupBy"`
+	// AssigneeIdentifier selects the label value when grouping by assignee
+	// or reporter: displayName (the default) or accountId.
+	AssigneeIdentifier string `yaml:"assigneeIdentifier"`
+	// EpicField is the ID of the Epic Link custom field used when grouping
+	// by epic, e.g. customfield_10014. The parent is used if empty.
+	EpicField string `yaml:"epicField"`
+	// Path selects the values of metrics grouped by path.
+	Path *pathConfiguration `yaml:"path"`
+	// Fields replaces the fields requested for metrics that look at
+	// individual issues with *all or *navigable.
+	Fields string `yaml:"fields"`
+	// EmptyGroup is the group of issues without any value for the groupBy
+	// field.
+	EmptyGroup string `yaml:"emptyGroup"`
+	// IncludeValues and ExcludeValues limit the groups that get their own
+	// series, all other values are counted as "other".
+	IncludeValues []string `yaml:"includeValues"`
+	ExcludeValues []string `yaml:"excludeValues"`
+	valueFilter   *valueFilter
+	// Normalize lists the steps applied to group values before they are
+	// used as labels: trim, lowercase and collapseWhitespace.
+	Normalize []string `yaml:"normalize"`
+	// LabelSanitization is the labelSanitization mode of the configuration,
+	// applied to group values.
+	LabelSanitization string `yaml:"-"`
+	// Subtasks is either "include" (the default), "exclude" or "separate"
+	// to count sub-tasks in a jira_<name>_subtasks gauge instead.
+	Subtasks string `yaml:"subtasks"`
+	// TopN, if set, only exports the N largest groups and counts the rest
+	// as "other".
+	TopN int `yaml:"topN"`
+	// MaxSeries limits the number of groups of grouped metrics like TopN
+	// as a safety net against unexpected cardinality (default 500).
+	MaxSeries int `yaml:"maxSeries"`
+	// Type is either "count" (the default), "resolutionTime", "timeSpent",
+	// "sla", "boardColumns", "epicProgress" or "sprint".
+	Type string `yaml:"type"`
+	// SLAField is the ID of the Jira Service Management SLA custom field
+	// used by sla metrics, e.g. customfield_10030.
+	SLAField string `yaml:"slaField"`
+	// AtRiskThresholds are the remaining times below which a request
+	// counts as being at risk of breaching its SLA.
+	AtRiskThresholds       []string `yaml:"atRiskThresholds"`
+	ParsedAtRiskThresholds []time.Duration
+	// Aggregates are computed for resolutionTime metrics.
+	Aggregates []string `yaml:"aggregates"`
+	Interval   string   `yaml:"interval"`
+	// Align makes scrapes happen on the multiples of the interval, e.g. on
+	// the full hour for 1h, instead of relative to the start.
+	Align bool `yaml:"align"`
+	// Schedule, if set, uses a different interval during business hours.
+	Schedule *scheduleConfiguration `yaml:"schedule"`
+	// Timeout limits the duration of a single scrape of the metric
+	// including all pages. No limit is applied if empty.
+	Timeout string `yaml:"timeout"`
+	// QueryBudget limits the time spent paginating during a single scrape.
+	// Once exceeded, the issues fetched so far are reported as a partial
+	// result.
+	QueryBudget string            `yaml:"queryBudget"`
+	Labels      map[string]string `yaml:"labels"`
+	// Window, if set, only counts issues within a trailing time window.
+	Window *windowConfiguration `yaml:"window"`
+	// DistinctCount exports the number of distinct values of the groupBy
+	// field instead of one series per value.
+	DistinctCount bool `yaml:"distinctCount"`
+	// WeightedCount, if set, sums up a weight per issue instead of
+	// counting issues.
+	WeightedCount *weightedCountConfiguration `yaml:"weightedCount"`
+	// MaxEpics limits the number of epics considered by epicProgress
+	// metrics per scrape.
+	MaxEpics int `yaml:"maxEpics"`
+	// Board is the Agile board of boardColumns and sprint metrics.
+	Board *boardConfiguration `yaml:"board"`
+	// DiscoverProjects, if set, runs the JQL once per project of the Jira
+	// instance with the project's key as {{ .project }}.
+	DiscoverProjects *projectDiscoveryConfiguration `yaml:"discoverProjects"`
+	// Variables expand the metric into one worker per combination of their
+	// values, see expandMatrix.
+	Variables map[string][]string `yaml:"variables"`
+	// MatrixValues is the combination of variable values of an expanded
+	// metric.
+	MatrixValues map[string]string `yaml:"-"`
+	// InvalidQuery is the reason a metric is disabled by
+	// --validate-queries=disable.
+	InvalidQuery   error `yaml:"-"`
+	ParsedInterval time.Duration
+	ParsedTimeout  time.Duration
+	ParsedBudget   time.Duration
+	Gauge          prometheus.Gauge
+	GaugeVec       *prometheus.GaugeVec
+	SubtaskGauge   prometheus.Gauge
+	SeriesCount    prometheus.Gauge
+	SeriesLimitHit prometheus.Gauge
+	Errors         *prometheus.CounterVec
+	Up             prometheus.Gauge
+	InvalidJQL     prometheus.Gauge
+	Partial        prometheus.Gauge
+	LastError      *prometheus.GaugeVec
+	Endpoint       *prometheus.GaugeVec
+	Skipped        prometheus.Counter
+	Panics         prometheus.Counter
+	LastTick       prometheus.Gauge
+	Duration       prometheus.Observer
+}
+
+// version is set at build time.
+var version = "dev"
+
+// metricDefaults contains values that are applied to every metric that
+// doesn't specify them itself.
+type metricDefaults struct {
+	Interval string            `yaml:"interval"`
+	Timeout  string            `yaml:"timeout"`
+	Labels   map[string]string `yaml:"labels"`
+	// GroupBy is only applied to metrics of types supporting it.
+	GroupBy string `yaml:"groupBy"`
+}
+
+const (
+	authModeBasic = "basic"
+	authModeNone  = "none"
+)
+
+// httpClientConfiguration tunes the transport used for talking to Jira. The
+// defaults are those of Go's default transport.
+type httpClientConfiguration struct {
+	// MaxIdleConnsPerHost is the number of keep-alive connections kept per
+	// host. 0 uses Go's default of 2.
+	MaxIdleConnsPerHost int `yaml:"maxIdleConnsPerHost"`
+	// IdleConnTimeout closes keep-alive connections that have been idle for
+	// this long.
+	IdleConnTimeout       string        `yaml:"idleConnTimeout"`
+	ParsedIdleConnTimeout time.Duration `yaml:"-"`
+	DisableKeepAlives     bool          `yaml:"disableKeepAlives"`
+	// DisableCompression stops requesting gzip compressed responses.
+	DisableCompression bool `yaml:"disableCompression"`
+	// DialTimeout limits how long establishing a connection may take.
+	DialTimeout       string        `yaml:"dialTimeout"`
+	ParsedDialTimeout time.Duration `yaml:"-"`
+	// KeepAlive is the interval of TCP keep-alive probes. Negative values
+	// disable them.
+	KeepAlive       string        `yaml:"keepAlive"`
+	ParsedKeepAlive time.Duration `yaml:"-"`
+	// TLS configures the connections to Jira.
+	TLS tlsConfiguration `yaml:"tls"`
+}
+
+// Defaults of the dialer, which are those of Go's default transport.
+const (
+	defaultDialTimeout = 30 * time.Second
+	defaultKeepAlive   = 30 * time.Second
+)
+
+type authConfiguration struct {
+	// Mode is either "basic" (the default) or "none" for anonymous access.
+	Mode string `yaml:"mode"`
+}
+
+// defaultMaxResponseBytes limits the size of a single Jira response body if
+// no maxResponseBytes has been configured.
+const defaultMaxResponseBytes = 16 * 1024 * 1024
+
+// defaultAPIPath is the path of Jira's search API relative to the base URL.
+const defaultAPIPath = "/rest/api/2/search"
+
+// defaultMinInterval is the smallest interval allowed for a metric unless
+// minInterval has been configured or fast intervals are explicitly allowed.
+const defaultMinInterval = 30 * time.Second
+
+// Reasons used for the reason label of the scrape error counter.
+const (
+	errorReasonRequest      = "request"
+	errorReasonStatus       = "status"
+	errorReasonDecode       = "decode"
+	errorReasonBodyTooLarge = "body_too_large"
+	errorReasonTemplate     = "template"
+	// errorReasonInvalidResponse is used if the body is not a search
+	// result at all.
+	errorReasonInvalidResponse = "invalid_response"
+)
+
+// Configuration is the configuration of the exporter as loaded by
+// LoadConfiguration.
+type Configuration struct {
+	BaseURL          string            `yaml:"baseURL"`
+	APIPath          string            `yaml:"apiPath"`
+	Login            string            `yaml:"login"`
+	Password         string            `yaml:"password"`
+	Auth             authConfiguration `yaml:"auth"`
+	MaxResponseBytes int64             `yaml:"maxResponseBytes"`
+	// Endpoints replace baseURL with several base URLs that are tried in
+	// order until one of them answers.
+	Endpoints []endpointConfiguration `yaml:"endpoints"`
+	// SuccessStatusCodes are the HTTP status codes of successful search
+	// responses. Defaults to 200 only.
+	SuccessStatusCodes []int  `yaml:"successStatusCodes"`
+	UserAgent          string `yaml:"userAgent"`
+	LabelSanitization  string `yaml:"labelSanitization"`
+	MinInterval        string `yaml:"minInterval"`
+	// ErrorLogInterval is the cadence of summaries of repeated scrape
+	// errors.
+	ErrorLogInterval       string                      `yaml:"errorLogInterval"`
+	ParsedErrorLogInterval time.Duration               `yaml:"-"`
+	CircuitBreakerConfig   circuitBreakerConfiguration `yaml:"circuitBreaker"`
+	// ProxyURL is nil if no proxy has been configured, in which case the
+	// proxy is taken from the environment. An empty value forces a direct
+	// connection.
+	ProxyURL   *string                 `yaml:"proxyURL"`
+	HTTPClient httpClientConfiguration `yaml:"httpClient"`
+	OTLP       otlpConfiguration       `yaml:"otlp"`
+	StatsD     statsdConfiguration     `yaml:"statsd"`
+	// ScrapeDuration selects how jira_scrape_duration_seconds is exported.
+	ScrapeDuration scrapeDurationConfiguration `yaml:"scrapeDuration"`
+	Probe          *probeConfiguration         `yaml:"probe"`
+	// Variables can be used inside the JQL of all metrics.
+	Variables map[string]string `yaml:"variables"`
+	// ExportIssuesTotal enables the jira_issues_total gauge.
+	ExportIssuesTotal bool                  `yaml:"exportIssuesTotal"`
+	Defaults          metricDefaults        `yaml:"defaults"`
+	Metrics           []MetricConfiguration `yaml:"metrics"`
+	HTTPHeaders       map[string]string     `yaml:"httpHeaders"`
+	// Include lists files whose metrics are added to Metrics.
+	Include []string `yaml:"include"`
+
+	// The runtime state of the exporter is set up by Setup.
+	circuitBreakers []*circuitBreaker
+	// scrapeTrigger forces an immediate scrape of all metrics.
+	scrapeTrigger *scrapeTrigger
+	statsdClient  *statsdClient
+	issuesTotal   *issuesTotal
+	scrapeStates  *scrapeStates
+	activeWorkers prometheus.Gauge
+}
+
+// LoadConfiguration reads the configuration file at path and validates it.
+// Metric intervals below the minimum interval are only accepted if
+// allowFastIntervals is set. The password is taken from the JIRA_PASSWORD
+// environment variable if the file doesn't contain one.
+func LoadConfiguration(path string, allowFastIntervals bool) (*Configuration, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+	cfg := &Configuration{}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, errors.Wrap(err, "failed to parse config data")
+	}
+
+	if cfg.Password == "" {
+		cfg.Password = os.Getenv("JIRA_PASSWORD")
+	}
+
+	switch cfg.Auth.Mode {
+	case "":
+		cfg.Auth.Mode = authModeBasic
+	case authModeBasic, authModeNone:
+	default:
+		return nil, errors.Errorf("unsupported auth mode %q", cfg.Auth.Mode)
+	}
+
+	if err := validateEndpoints(cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.APIPath == "" {
+		cfg.APIPath = defaultAPIPath
+	}
+	if !strings.HasPrefix(cfg.APIPath, "/") {
+		return nil, errors.Errorf("apiPath %q must start with /", cfg.APIPath)
+	}
+
+	if cfg.MaxResponseBytes < 0 {
+		return nil, errors.New("maxResponseBytes must not be negative")
+	}
+	if cfg.MaxResponseBytes == 0 {
+		cfg.MaxResponseBytes = defaultMaxResponseBytes
+	}
+
+	for _, code := range cfg.SuccessStatusCodes {
+		if code < 100 || code > 599 {
+			return nil, errors.Errorf("invalid successStatusCodes entry %d", code)
+		}
+	}
+
+	if cfg.LabelSanitization == "" {
+		cfg.LabelSanitization = sanitizePassthrough
+	}
+	if err := validateSanitizationMode(cfg.LabelSanitization); err != nil {
+		return nil, err
+	}
+
+	if cfg.ProxyURL != nil && *cfg.ProxyURL != "" {
+		if _, err := url.Parse(*cfg.ProxyURL); err != nil {
+			return nil, errors.Wrap(err, "invalid proxyURL")
+		}
+	}
+
+	if cfg.HTTPClient.MaxIdleConnsPerHost < 0 {
+		return nil, errors.New("httpClient.maxIdleConnsPerHost must not be negative")
+	}
+	if cfg.HTTPClient.IdleConnTimeout != "" {
+		cfg.HTTPClient.ParsedIdleConnTimeout, err = time.ParseDuration(cfg.HTTPClient.IdleConnTimeout)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid httpClient.idleConnTimeout")
+		}
+		if cfg.HTTPClient.ParsedIdleConnTimeout < 0 {
+			return nil, errors.New("httpClient.idleConnTimeout must not be negative")
+		}
+	}
+	cfg.HTTPClient.ParsedDialTimeout = defaultDialTimeout
+	if cfg.HTTPClient.DialTimeout != "" {
+		cfg.HTTPClient.ParsedDialTimeout, err = time.ParseDuration(cfg.HTTPClient.DialTimeout)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid httpClient.dialTimeout")
+		}
+		if cfg.HTTPClient.ParsedDialTimeout <= 0 {
+			return nil, errors.New("httpClient.dialTimeout must be positive")
+		}
+	}
+	cfg.HTTPClient.ParsedKeepAlive = defaultKeepAlive
+	if cfg.HTTPClient.KeepAlive != "" {
+		cfg.HTTPClient.ParsedKeepAlive, err = time.ParseDuration(cfg.HTTPClient.KeepAlive)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid httpClient.keepAlive")
+		}
+	}
+
+	if cfg.CircuitBreakerConfig.Threshold < 0 {
+		return nil, errors.New("circuitBreaker.threshold must not be negative")
+	}
+	if cfg.CircuitBreakerConfig.Cooldown != "" {
+		cfg.CircuitBreakerConfig.ParsedCooldown, err = time.ParseDuration(cfg.CircuitBreakerConfig.Cooldown)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid circuitBreaker.cooldown")
+		}
+	}
+
+	if cfg.ErrorLogInterval != "" {
+		cfg.ParsedErrorLogInterval, err = time.ParseDuration(cfg.ErrorLogInterval)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid errorLogInterval")
+		}
+	}
+
+	if cfg.OTLP.Interval != "" {
+		cfg.OTLP.ParsedInterval, err = time.ParseDuration(cfg.OTLP.Interval)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid otlp.interval")
+		}
+	}
+
+	if err := validateStatsDConfiguration(cfg.StatsD); err != nil {
+		return nil, err
+	}
+
+	if err := validateScrapeDuration(&cfg.ScrapeDuration); err != nil {
+		return nil, err
+	}
+
+	if err := validateProbe(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := loadIncludes(cfg, path); err != nil {
+		return nil, err
+	}
+
+	minInterval := defaultMinInterval
+	if cfg.MinInterval != "" {
+		minInterval, err = time.ParseDuration(cfg.MinInterval)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid minInterval")
+		}
+	}
+
+	expanded := make([]MetricConfiguration, 0, len(cfg.Metrics))
+	for _, m := range cfg.Metrics {
+		if m.Enabled != nil && !*m.Enabled {
+			continue
+		}
+		applyDefaults(&m, cfg.Defaults)
+		if m.JQL == "" && m.JQLFile != "" {
+			jql, err := loadJQLFile(path, m.JQLFile)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to load JQL for metric %s", m.Name)
+			}
+			m.JQL = jql
+		}
+		metrics, err := expandMatrix(m)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid variables for metric %s", m.Name)
+		}
+		expanded = append(expanded, metrics...)
+	}
+	cfg.Metrics = expanded
+
+	for i := 0; i < len(cfg.Metrics); i++ {
+		cfg.Metrics[i].Name = sanitizeMetricName(cfg.LabelSanitization, cfg.Metrics[i].Name)
+		cfg.Metrics[i].LabelSanitization = cfg.LabelSanitization
+		for k, v := range cfg.Metrics[i].Labels {
+			cfg.Metrics[i].Labels[k] = sanitizeLabelValue(cfg.LabelSanitization, v)
+		}
+		if err := validateProjectDiscovery(&cfg.Metrics[i]); err != nil {
+			return nil, errors.Wrapf(err, "invalid metric %s", cfg.Metrics[i].Name)
+		}
+		rendered := cfg.Metrics[i]
+		if rendered.DiscoverProjects != nil {
+			rendered = withProject(rendered, "PROJECT")
+		}
+		if _, err := renderJQL(rendered, cfg.Variables, time.Now()); err != nil {
+			return nil, errors.Wrapf(err, "invalid JQL for metric %s", metricID(cfg.Metrics[i]))
+		}
+		if err := validateMetricType(&cfg.Metrics[i]); err != nil {
+			return nil, errors.Wrapf(err, "invalid metric %s", cfg.Metrics[i].Name)
+		}
+		if cfg.Metrics[i].GroupBy != "" {
+			if _, err := lookupGrouper(cfg.Metrics[i].GroupBy); err != nil {
+				return nil, errors.Wrapf(err, "invalid groupBy for metric %s", cfg.Metrics[i].Name)
+			}
+		}
+		if cfg.Metrics[i].GroupBy == "" && (len(cfg.Metrics[i].IncludeValues) > 0 || len(cfg.Metrics[i].ExcludeValues) > 0) {
+			return nil, errors.Errorf("includeValues and excludeValues require groupBy for metric %s", cfg.Metrics[i].Name)
+		}
+		if err := validateDistinctCount(cfg.Metrics[i]); err != nil {
+			return nil, errors.Wrapf(err, "invalid metric %s", cfg.Metrics[i].Name)
+		}
+		if err := validateWeightedCount(&cfg.Metrics[i]); err != nil {
+			return nil, errors.Wrapf(err, "invalid metric %s", cfg.Metrics[i].Name)
+		}
+		if err := validatePath(&cfg.Metrics[i]); err != nil {
+			return nil, errors.Wrapf(err, "invalid metric %s", cfg.Metrics[i].Name)
+		}
+		if err := validateNormalize(cfg.Metrics[i]); err != nil {
+			return nil, errors.Wrapf(err, "invalid metric %s", cfg.Metrics[i].Name)
+		}
+		if err := validateSubtasks(cfg.Metrics[i]); err != nil {
+			return nil, errors.Wrapf(err, "invalid metric %s", cfg.Metrics[i].Name)
+		}
+		if cfg.Metrics[i].TopN < 0 {
+			return nil, errors.Errorf("topN must not be negative for metric %s", cfg.Metrics[i].Name)
+		}
+		if cfg.Metrics[i].MaxSeries < 0 {
+			return nil, errors.Errorf("maxSeries must not be negative for metric %s", cfg.Metrics[i].Name)
+		}
+		if cfg.Metrics[i].MaxSeries == 0 {
+			cfg.Metrics[i].MaxSeries = defaultMaxSeries
+		}
+		if cfg.Metrics[i].GroupBy == "" && cfg.Metrics[i].TopN > 0 {
+			return nil, errors.Errorf("topN requires groupBy for metric %s", cfg.Metrics[i].Name)
+		}
+		// Grouping by user can create a series per user of the instance.
+		if (cfg.Metrics[i].GroupBy == "assignee" || cfg.Metrics[i].GroupBy == "reporter") && cfg.Metrics[i].TopN == 0 && !cfg.Metrics[i].DistinctCount && len(cfg.Metrics[i].IncludeValues) == 0 {
+			return nil, errors.Errorf("groupBy %s requires topN or includeValues for metric %s", cfg.Metrics[i].GroupBy, cfg.Metrics[i].Name)
+		}
+		cfg.Metrics[i].valueFilter, err = newValueFilter(cfg.Metrics[i].IncludeValues, cfg.Metrics[i].ExcludeValues)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid metric %s", cfg.Metrics[i].Name)
+		}
+		switch cfg.Metrics[i].Fields {
+		case "", fieldsAll, fieldsNavigable:
+		default:
+			return nil, errors.Errorf("unsupported fields %q for metric %s (supported: %s, %s)", cfg.Metrics[i].Fields, cfg.Metrics[i].Name, fieldsAll, fieldsNavigable)
+		}
+		if cfg.Metrics[i].EpicField != "" && !strings.HasPrefix(cfg.Metrics[i].EpicField, customFieldPrefix) {
+			return nil, errors.Errorf("epicField must be a custom field ID like customfield_10014 for metric %s", cfg.Metrics[i].Name)
+		}
+		switch cfg.Metrics[i].AssigneeIdentifier {
+		case "", assigneeDisplayName, assigneeAccountID:
+		default:
+			return nil, errors.Errorf("invalid assigneeIdentifier %q for metric %s", cfg.Metrics[i].AssigneeIdentifier, cfg.Metrics[i].Name)
+		}
+		// Set a default value of 5 minutes if none has been specified.
+		if cfg.Metrics[i].Interval == "" {
+			cfg.Metrics[i].Interval = "5m"
+		}
+		dur, err := time.ParseDuration(cfg.Metrics[i].Interval)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid interval for metric %d", i)
+		}
+		if dur < minInterval && !allowFastIntervals {
+			return nil, errors.Errorf("interval %s of metric %s is below the minimum of %s", dur, cfg.Metrics[i].Name, minInterval)
+		}
+		if err := validateSchedule(&cfg.Metrics[i], minInterval, allowFastIntervals); err != nil {
+			return nil, errors.Wrapf(err, "invalid metric %s", cfg.Metrics[i].Name)
+		}
+		cfg.Metrics[i].ParsedInterval = dur
+		if cfg.Metrics[i].Window != nil {
+			if err := validateWindow(cfg.Metrics[i].Window); err != nil {
+				return nil, errors.Wrapf(err, "invalid metric %s", cfg.Metrics[i].Name)
+			}
+		}
+		if cfg.Metrics[i].Timeout != "" {
+			cfg.Metrics[i].ParsedTimeout, err = time.ParseDuration(cfg.Metrics[i].Timeout)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid timeout for metric %s", cfg.Metrics[i].Name)
+			}
+		}
+		if cfg.Metrics[i].QueryBudget != "" {
+			cfg.Metrics[i].ParsedBudget, err = time.ParseDuration(cfg.Metrics[i].QueryBudget)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid queryBudget for metric %s", cfg.Metrics[i].Name)
+			}
+			if cfg.Metrics[i].ParsedBudget <= 0 {
+				return nil, errors.Errorf("queryBudget of metric %s must be positive", cfg.Metrics[i].Name)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// loadJQLFile reads a JQL query from the given file. Relative paths are
+// resolved against the directory of the configuration file.
+func loadJQLFile(configPath string, jqlPath string) (string, error) {
+	if !filepath.IsAbs(jqlPath) && configPath != "-" {
+		jqlPath = filepath.Join(filepath.Dir(configPath), jqlPath)
+	}
+	data, err := ioutil.ReadFile(jqlPath)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRightFunc(string(data), unicode.IsSpace), nil
+}
+
+// supportsGroupBy reports whether metrics of the given metric's type can be
+// grouped.
+func supportsGroupBy(m MetricConfiguration) bool {
+	switch m.Type {
+	case "", metricTypeCount, metricTypeTimeSpent, metricTypeSprint:
+		return m.DiscoverProjects == nil
+	}
+	return false
+}
+
+// applyDefaults fills in all values of the metric that have not been set
+// explicitly. Labels are merged with the metric's own labels taking
+// precedence.
+func applyDefaults(m *MetricConfiguration, defaults metricDefaults) {
+	if m.Interval == "" {
+		m.Interval = defaults.Interval
+	}
+	if m.Timeout == "" {
+		m.Timeout = defaults.Timeout
+	}
+	if m.GroupBy == "" && supportsGroupBy(*m) {
+		m.GroupBy = defaults.GroupBy
+	}
+	if len(defaults.Labels) > 0 {
+		labels := make(map[string]string, len(defaults.Labels)+len(m.Labels))
+		for k, v := range defaults.Labels {
+			labels[k] = v
+		}
+		for k, v := range m.Labels {
+			labels[k] = v
+		}
+		m.Labels = labels
+	}
+}
+
+type pagedResponse struct {
+	Total uint64 `json:"total"`
+	// Issues is the number of issues contained in the response.
+	Issues int `json:"-"`
+	// WarningMessages are reported by Jira e.g. for JQL values that don't
+	// exist while the query is still executed.
+	WarningMessages []string `json:"warningMessages"`
+}
+
+// decodePagedResponse walks the top-level JSON object of a search response and
+// only extracts the fields we are interested in. Issues are decoded one by one
+// and passed to onIssue so that a potentially huge issues list never has to be
+// held in memory as a whole. If onIssue is nil, issues are skipped. An error
+// returned by onIssue aborts decoding. All other values are skipped token by
+// token.
+func decodePagedResponse(r io.Reader, onIssue func(issue) error) (pagedResponse, error) {
+	pr := pagedResponse{}
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return pr, &invalidResponseError{msg: "response is not JSON"}
+	}
+	if err != nil {
+		return pr, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return pr, &invalidResponseError{msg: "response is not a JSON object"}
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return pr, err
+		}
+		key, _ := tok.(string)
+		if key == "total" {
+			var typeErr *json.UnmarshalTypeError
+			if err := dec.Decode(&pr.Total); errors.As(err, &typeErr) {
+				return pr, &invalidResponseError{msg: "total is not a number"}
+			} else if err != nil {
+				return pr, errors.Wrap(err, "failed to decode total")
+			}
+			continue
+		}
+		if key == "warningMessages" {
+			if err := dec.Decode(&pr.WarningMessages); err != nil {
+				return pr, errors.Wrap(err, "failed to decode warningMessages")
+			}
+			continue
+		}
+		if key == "issues" && onIssue != nil {
+			count, err := decodeIssues(dec, onIssue)
+			if err != nil {
+				return pr, errors.Wrap(err, "failed to decode issues")
+			}
+			pr.Issues = count
+			continue
+		}
+		if err := skipValue(dec); err != nil {
+			return pr, err
+		}
+	}
+	_, err = dec.Token()
+	return pr, err
+}
+
+// decodeIssues decodes the elements of an issues array one at a time.
+func decodeIssues(dec *json.Decoder, onIssue func(issue) error) (int, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return 0, err
+	}
+	if tok == nil {
+		return 0, nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return 0, &invalidResponseError{msg: "issues is not a JSON array"}
+	}
+	count := 0
+	for dec.More() {
+		var i issue
+		if err := dec.Decode(&i); err != nil {
+			return count, err
+		}
+		if err := onIssue(i); err != nil {
+			return count, err
+		}
+		count++
+	}
+	_, err = dec.Token()
+	return count, err
+}
+
+// skipValue consumes the next JSON value from the decoder without
+// materializing it.
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if delim, ok := tok.(json.Delim); ok {
+			switch delim {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// recordError increments the error counter of the given metric for the
+// provided reason.
+func recordError(m MetricConfiguration, reason string) {
+	if m.Errors == nil {
+		return
+	}
+	m.Errors.WithLabelValues(metricID(m), reason).Inc()
+}
+
+// newDialer returns the dialer used for connections to Jira. Unset values
+// use the defaults of Go's default transport.
+func newDialer(cfg httpClientConfiguration) *net.Dialer {
+	d := &net.Dialer{Timeout: defaultDialTimeout, KeepAlive: defaultKeepAlive}
+	if cfg.ParsedDialTimeout != 0 {
+		d.Timeout = cfg.ParsedDialTimeout
+	}
+	if cfg.ParsedKeepAlive != 0 {
+		d.KeepAlive = cfg.ParsedKeepAlive
+	}
+	return d
+}
+
+// newHTTPClient creates the client used for talking to Jira based on the
+// proxy and transport settings of the configuration.
+func newHTTPClient(cfg *Configuration) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = newDialer(cfg.HTTPClient).DialContext
+	tlsConfig, err := cfg.HTTPClient.TLS.build()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid httpClient.tls")
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	if cfg.HTTPClient.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = cfg.HTTPClient.MaxIdleConnsPerHost
+	}
+	if cfg.HTTPClient.ParsedIdleConnTimeout > 0 {
+		transport.IdleConnTimeout = cfg.HTTPClient.ParsedIdleConnTimeout
+	}
+	transport.DisableKeepAlives = cfg.HTTPClient.DisableKeepAlives
+	transport.DisableCompression = cfg.HTTPClient.DisableCompression
+	if cfg.ProxyURL != nil {
+		if *cfg.ProxyURL == "" {
+			transport.Proxy = nil
+		} else {
+			proxyURL, err := url.Parse(*cfg.ProxyURL)
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid proxyURL")
+			}
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// Values of the fields setting of a metric. Jira returns all fields of an
+// issue for the former and all fields shown in the issue navigator for the
+// latter.
+const (
+	fieldsAll       = "*all"
+	fieldsNavigable = "*navigable"
+)
+
+// searchFields returns the issue fields that have to be requested for the
+// given metric. An empty string means that only the total is needed.
+func searchFields(m MetricConfiguration) string {
+	fields := metricFields(m)
+	if !filtersSubtasks(m) {
+		return fields
+	}
+	if fields == "" {
+		return "issuetype"
+	}
+	return fields + ",issuetype"
+}
+
+// metricFields returns the issue fields needed by the metric's type and
+// grouping.
+func metricFields(m MetricConfiguration) string {
+	switch {
+	case m.Type == metricTypeResolutionTime:
+		return "created,resolutiondate"
+	case m.Type == metricTypeTimeSpent && m.GroupBy != "":
+		return "timespent," + groupers[m.GroupBy].requestedField(m)
+	case m.Type == metricTypeTimeSpent:
+		return "timespent"
+	case m.Type == metricTypeSLA:
+		return m.SLAField
+	case m.Type == metricTypeEpicProgress:
+		return "status," + groupers["epic"].requestedField(m)
+	case m.WeightedCount != nil && m.GroupBy != "":
+		return m.WeightedCount.grouper.requestedField(m) + "," + groupers[m.GroupBy].requestedField(m)
+	case m.WeightedCount != nil:
+		return m.WeightedCount.grouper.requestedField(m)
+	case m.GroupBy != "":
+		return groupers[m.GroupBy].requestedField(m)
+	}
+	return ""
+}
+
+// searchURL returns the URL of the search request issued for the given
+// metric. Grouped metrics request the page of issues starting at startAt
+// while all other metrics only ask for the total.
+func searchURL(cfg *Configuration, m MetricConfiguration, startAt int) string {
+	params := url.Values{}
+	params.Set("jql", m.JQL)
+	if fields := searchFields(m); fields == "" {
+		params.Set("maxResults", "0")
+	} else {
+		if m.Fields != "" {
+			fields = m.Fields
+		}
+		params.Set("fields", fields)
+		params.Set("maxResults", strconv.Itoa(searchPageSize))
+		if startAt > 0 {
+			params.Set("startAt", strconv.Itoa(startAt))
+		}
+	}
+	return apiURL(cfg, params)
+}
+
+// apiURL returns the URL of the search API with the given parameters.
+func apiURL(cfg *Configuration, params url.Values) string {
+	apiPath := cfg.APIPath
+	if apiPath == "" {
+		apiPath = defaultAPIPath
+	}
+	return fmt.Sprintf("%s%s?%s", cfg.BaseURL, apiPath, params.Encode())
+}
+
+// printURLs writes the search URL of every configured metric to w using the
+// first endpoint. Any credentials that are part of the base URL are redacted.
+func printURLs(w io.Writer, cfg *Configuration) error {
+	primary := endpointConfigs(cfg)[0]
+	now := time.Now()
+	for _, m := range cfg.Metrics {
+		m, err := renderMetric(m, cfg.Variables, now)
+		if err != nil {
+			return errors.Wrapf(err, "invalid JQL for metric %s", metricID(m))
+		}
+		u, err := url.Parse(searchURL(primary, m, 0))
+		if err != nil {
+			return errors.Wrapf(err, "invalid URL for metric %s", metricID(m))
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s\n", metricID(m), u.Redacted()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Values of the reason label of the last error info metric.
+const (
+	lastErrorNetwork   = "network"
+	lastErrorTimeout   = "timeout"
+	lastErrorHTTP4xx   = "http_4xx"
+	lastErrorHTTP5xx   = "http_5xx"
+	lastErrorHTTPOther = "http_other"
+	lastErrorDecode    = "decode"
+	lastErrorInvalid   = "invalid_response"
+	lastErrorTemplate  = "template"
+)
+
+// classifyError maps a scrape error onto a small set of reasons that are safe
+// to be used as label values. A nil error results in an empty reason.
+func classifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+	var scrapeErr *scrapeError
+	if !errors.As(err, &scrapeErr) {
+		return lastErrorNetwork
+	}
+	switch scrapeErr.reason {
+	case errorReasonStatus:
+		switch {
+		case scrapeErr.statusCode >= 500:
+			return lastErrorHTTP5xx
+		case scrapeErr.statusCode >= 400:
+			return lastErrorHTTP4xx
+		}
+		return lastErrorHTTPOther
+	case errorReasonDecode, errorReasonBodyTooLarge:
+		return lastErrorDecode
+	case errorReasonInvalidResponse:
+		return lastErrorInvalid
+	case errorReasonTemplate:
+		return lastErrorTemplate
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return lastErrorTimeout
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return lastErrorTimeout
+	}
+	return lastErrorNetwork
+}
+
+// setLastError replaces the last error info series of the given metric. The
+// series of the previous reason is removed so that at most one series exists
+// per metric. It returns the new reason.
+func setLastError(m MetricConfiguration, previous string, reason string) string {
+	if m.LastError == nil || previous == reason {
+		return reason
+	}
+	if previous != "" {
+		m.LastError.DeleteLabelValues(metricID(m), previous)
+	}
+	if reason != "" {
+		m.LastError.WithLabelValues(metricID(m), reason).Set(1)
+	}
+	return reason
+}
+
+// skipScrape counts a scrape of the given metric that was skipped because
+// the previous one was still running.
+func skipScrape(m MetricConfiguration) {
+	if m.Skipped != nil {
+		m.Skipped.Inc()
+	}
+}
+
+// recordTick updates the heartbeat of the worker of the given metric.
+func recordTick(m MetricConfiguration) {
+	if m.LastTick != nil {
+		m.LastTick.SetToCurrentTime()
+	}
+}
+
+// setUp updates the up gauge of the given metric.
+func setUp(m MetricConfiguration, up bool) {
+	if m.Up == nil {
+		return
+	}
+	if up {
+		m.Up.Set(1)
+	} else {
+		m.Up.Set(0)
+	}
+}
+
+// setPartial updates the partial result gauge of the given metric.
+func setPartial(m MetricConfiguration, partial bool) {
+	if m.Partial == nil {
+		return
+	}
+	if partial {
+		m.Partial.Set(1)
+	} else {
+		m.Partial.Set(0)
+	}
+}
+
+// scrapeError describes why a single scrape of a metric failed.
+type scrapeError struct {
+	reason     string
+	statusCode int
+	// message is the error message sent by Jira, if any.
+	message string
+	err     error
+}
+
+func (e *scrapeError) Error() string {
+	if e.statusCode != 0 && e.message != "" {
+		return fmt.Sprintf("%s: HTTP response had unexpected status %d: %s", e.reason, e.statusCode, e.message)
+	}
+	if e.statusCode != 0 {
+		return fmt.Sprintf("%s: HTTP response had unexpected status %d", e.reason, e.statusCode)
+	}
+	return fmt.Sprintf("%s: %s", e.reason, e.err)
+}
+
+func (e *scrapeError) Unwrap() error {
+	return e.err
+}
+
+// endpointScraper executes the search requests against Jira.
+type endpointScraper struct {
+	*jiraClient
+	cfg *Configuration
+}
+
+func newEndpointScraper(cfg *Configuration, client *http.Client) *endpointScraper {
+	return &endpointScraper{jiraClient: newJiraClient(cfg, client), cfg: cfg}
+}
+
+// scrapeResult is the outcome of scraping a single metric.
+type scrapeResult struct {
+	// Total is the number of issues matching the JQL.
+	Total uint64
+	// Value is exported by metrics using a plain gauge.
+	Value float64
+	// Groups contains the value per label value for metrics exported as a
+	// GaugeVec, e.g. the number of issues per group for grouped metrics.
+	Groups map[string]float64
+	// Subtasks is the number of sub-tasks not counted in Value or Groups.
+	Subtasks float64
+	// MissingFields is the number of issues without any fields.
+	MissingFields int
+	Pages         int
+	// SkippedEpics is the number of epics ignored because of maxEpics.
+	SkippedEpics uint64
+	// UnknownWeights are the values of weighted metrics that got the
+	// default weight.
+	UnknownWeights []string
+	// Partial is set if pagination stopped early because of the metric's
+	// query budget.
+	Partial bool
+	// ProjectErrors are the errors of the discovered projects that
+	// couldn't be counted.
+	ProjectErrors map[string]error
+}
+
+// scrape computes the value of the given metric once, aborting after the
+// metric's timeout. All errors returned are of type *scrapeError.
+func (s *endpointScraper) scrape(ctx context.Context, m MetricConfiguration) (scrapeResult, error) {
+	if m.ParsedTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.ParsedTimeout)
+		defer cancel()
+	}
+	switch {
+	case m.Type == metricTypeResolutionTime:
+		return s.scrapeResolutionTime(ctx, m)
+	case m.Type == metricTypeTimeSpent:
+		return s.scrapeTimeSpent(ctx, m)
+	case m.Type == metricTypeSLA:
+		return s.scrapeSLA(ctx, m)
+	case m.Type == metricTypeBoardColumns:
+		return s.scrapeBoardColumns(ctx, m)
+	case m.Type == metricTypeEpicProgress:
+		return s.scrapeEpicProgress(ctx, m)
+	case m.Type == metricTypeSprint:
+		return s.scrapeSprint(ctx, m)
+	case m.DistinctCount:
+		return s.scrapeDistinct(ctx, m)
+	case m.WeightedCount != nil:
+		return s.scrapeWeighted(ctx, m)
+	case m.GroupBy != "":
+		return s.scrapeGrouped(ctx, m)
+	case m.DiscoverProjects != nil:
+		return s.scrapeDiscovered(ctx, m)
+	case filtersSubtasks(m):
+		return s.scrapeCount(ctx, m)
+	}
+	pr, err := s.search(ctx, searchURL(s.cfg, m, 0), nil)
+	return scrapeResult{Total: pr.Total, Value: float64(pr.Total), Pages: 1}, err
+}
+
+func (s *endpointScraper) scrapeGrouped(ctx context.Context, m MetricConfiguration) (scrapeResult, error) {
+	g, err := lookupGrouper(m.GroupBy)
+	if err != nil {
+		return scrapeResult{}, &scrapeError{reason: errorReasonRequest, err: err}
+	}
+	groups := make(map[string]float64)
+	empty := 0.0
+	result, err := s.fetchIssues(ctx, m, func(i issue) error {
+		values := groupValues(g, i, m)
+		if len(values) == 0 {
+			empty++
+		}
+		for _, value := range values {
+			groups[value]++
+		}
+		return nil
+	})
+	result.Groups = topGroups(groups, m.TopN)
+	// Issues without any group are exported separately for some groupers.
+	result.Value = empty
+	return result, err
+}
+
+// fetchIssues pages through all issues matching the metric's JQL and passes
+// them to onIssue. The returned result contains the total and the number of
+// pages fetched.
+func (s *endpointScraper) fetchIssues(ctx context.Context, m MetricConfiguration, onIssue func(issue) error) (scrapeResult, error) {
+	var result scrapeResult
+	count := onIssue
+	onIssue = func(i issue) error {
+		if i.MissingFields {
+			result.MissingFields++
+		}
+		return count(i)
+	}
+	if filtersSubtasks(m) {
+		next := onIssue
+		onIssue = func(i issue) error {
+			if i.Fields.IssueType != nil && i.Fields.IssueType.Subtask {
+				result.Subtasks++
+				return nil
+			}
+			return next(i)
+		}
+	}
+	fetched := 0
+	start := time.Now()
+	for {
+		pr, err := s.search(ctx, searchURL(s.cfg, m, fetched), onIssue)
+		if err != nil {
+			return result, err
+		}
+		result.Pages++
+		result.Total = pr.Total
+		fetched += pr.Issues
+		if pr.Issues == 0 || uint64(fetched) >= pr.Total {
+			return result, nil
+		}
+		if m.ParsedBudget > 0 && time.Since(start) >= m.ParsedBudget {
+			result.Partial = true
+			return result, nil
+		}
+	}
+}
+
+// newScrapeID returns a short random identifier that is attached to all log
+// entries of a single scrape.
+func newScrapeID() string {
+	var id [4]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(id[:])
+}
+
+// Scraper runs a worker per metric of a configuration that scrapes the
+// metric's value at its interval and updates its gauges.
+type Scraper struct {
+	log    *logrus.Logger
+	cfg    *Configuration
+	client *http.Client
+}
+
+// NewScraper returns a scraper for the metrics of the given configuration,
+// which has to be prepared using Setup first.
+func NewScraper(log *logrus.Logger, cfg *Configuration, client *http.Client) *Scraper {
+	return &Scraper{log: log, cfg: cfg, client: client}
+}
+
+// Setup prepares a configuration returned by LoadConfiguration for scraping.
+// It registers the gauges of the metrics with registry and the exporter's own
+// metrics with telemetry, removes the metrics disabled by
+// --validate-queries=disable and creates the circuit breakers of the
+// endpoints. It must only be called once per configuration.
+func Setup(log *logrus.Logger, registry prometheus.Registerer, telemetry prometheus.Registerer, cfg *Configuration) error {
+	if err := checkPasswords(cfg); err != nil {
+		return err
+	}
+	if err := SetupGauges(registry, telemetry, cfg.Metrics); err != nil {
+		return errors.Wrap(err, "failed to setup gauges")
+	}
+	if err := setupConfigInfo(telemetry, cfg.Metrics); err != nil {
+		return errors.Wrap(err, "failed to setup configuration info")
+	}
+	warnUnconfigured(log, cfg.Metrics)
+	if err := setupScrapeDurations(telemetry, cfg.ScrapeDuration, cfg.Metrics); err != nil {
+		return errors.Wrap(err, "failed to setup scrape durations")
+	}
+	var err error
+	if cfg.activeWorkers, err = setupWorkerMetrics(telemetry, cfg.Metrics); err != nil {
+		return errors.Wrap(err, "failed to setup worker metrics")
+	}
+	if cfg.ExportIssuesTotal {
+		if cfg.issuesTotal, err = setupIssuesTotal(registry); err != nil {
+			return errors.Wrap(err, "failed to setup jira_issues_total")
+		}
+	}
+	if cfg.scrapeStates, err = setupScrapeStates(telemetry, cfg.Metrics); err != nil {
+		return errors.Wrap(err, "failed to setup jira_scrape_state")
+	}
+	disableMetrics(cfg)
+	if cfg.circuitBreakers, err = setupCircuitBreakers(telemetry, log, cfg); err != nil {
+		return errors.Wrap(err, "failed to setup circuit breakers")
+	}
+	cfg.scrapeTrigger = newScrapeTrigger()
+	return nil
+}
+
+// checkPasswords makes sure that every endpoint has a password unless
+// anonymous access has been configured.
+func checkPasswords(cfg *Configuration) error {
+	for i, e := range endpointConfigs(cfg) {
+		if e.Password == "" && cfg.Auth.Mode != authModeNone {
+			return errors.Errorf("no jira password for %s, please specify one via configuration or JIRA_PASSWORD environment variable", endpointName(cfg, i))
+		}
+	}
+	return nil
+}
+
+// Run scrapes the metrics until the context is cancelled. Scrapes that are
+// still running at that point are waited for.
+func (sc *Scraper) Run(ctx context.Context) {
+	log, cfg := sc.log, sc.cfg
+	failover := newFailoverScraper(log, cfg, sc.client)
+	primary := endpointConfigs(cfg)[0]
+	clockCtx, stopClock := context.WithCancel(ctx)
+	clockDone := make(chan struct{})
+	go func() {
+		defer close(clockDone)
+		watchClock(clockCtx, log, clockCheckPeriod, &clockMonitor{now: time.Now, threshold: clockJumpThreshold}, cfg.scrapeTrigger.fire)
+	}()
+	wg := sync.WaitGroup{}
+	wg.Add(len(cfg.Metrics))
+	for idx, m := range cfg.Metrics {
+		go func(idx int, m MetricConfiguration) {
+			defer wg.Done()
+			// inFlight is held while a scrape is running. It outlives restarts
+			// of the worker, so that a scrape left behind by a panicking worker
+			// finishes before the next one starts.
+			var inFlight sync.Mutex
+			superviseWorker(ctx, log, m, cfg.activeWorkers, workerRestartDelay, func() {
+				timer := newScrapeTicker(m)
+				defer timer.Stop()
+				lastErrorReason := ""
+				lastEndpoint := ""
+				errorLog := newErrorLogLimiter(cfg.ParsedErrorLogInterval)
+				var groups map[string]struct{}
+				// backoff is the number of intervals to skip after Jira rejected
+				// the JQL. Triggered scrapes are always executed.
+				backoff := 0
+				// scrapeOnce returns the error of the scrape, if any.
+				scrapeOnce := func(forced bool) error {
+					scrapeLog := log.WithField("scrape_id", newScrapeID())
+					if backoff > 0 && !forced {
+						backoff--
+						scrapeLog.Debugf("Skipping %s as its JQL is invalid", metricID(m))
+					} else if !failover.available() {
+						scrapeLog.Debugf("Skipping %s as the circuit breaker is open", metricID(m))
+					} else {
+						scrapeLog.Debugf("Checking %s", metricID(m))
+						// In-flight scrapes are not aborted on shutdown, so only
+						// the values of the worker context are passed on.
+						spanCtx, span := startScrapeSpan(context.WithoutCancel(ctx), m)
+						rendered, err := renderMetric(m, cfg.Variables, time.Now())
+						var result scrapeResult
+						var endpoint string
+						if err == nil {
+							scrapeLog.Debugf("JQL of %s: %s", metricID(m), rendered.JQL)
+							start := time.Now()
+							result, endpoint, err = failover.scrape(spanCtx, rendered)
+							if errors.Is(err, errCircuitOpen) {
+								// Another worker took the probe slot of the
+								// half-open breaker in the meantime.
+								endScrapeSpan(span, result, nil)
+								scrapeLog.Debugf("Skipping %s as the circuit breaker is open", metricID(m))
+								return nil
+							}
+							observeDuration(m, time.Since(start))
+						}
+						endScrapeSpan(span, result, err)
+						if err != nil {
+							cfg.scrapeStates.update(metricID(m), err)
+							errorLog.failure(scrapeLog.WithField("url", searchURL(primary, rendered, 0)), err, fmt.Sprintf("Failed to scrape %s", metricID(m)))
+							var scrapeErr *scrapeError
+							if errors.As(err, &scrapeErr) {
+								recordError(m, scrapeErr.reason)
+							}
+							setUp(m, false)
+						} else {
+							var observed int
+							result, observed = limitSeries(m, result)
+							setSeriesLimitHit(m, observed > 0)
+							if observed > 0 {
+								scrapeLog.Warnf("%s has %d groups, only exporting the largest %d as maxSeries is exceeded", metricID(m), observed, m.MaxSeries-1)
+							}
+							groups = updateGauge(cfg.Metrics[idx], result, groups)
+							cfg.issuesTotal.update(metricID(m), result.Total)
+							cfg.scrapeStates.update(metricID(m), nil)
+							sendStatsD(cfg.statsdClient, m, result)
+							setUp(m, true)
+							lastEndpoint = setEndpoint(m, lastEndpoint, endpoint)
+							errorLog.success(scrapeLog, fmt.Sprintf("Scraping %s works again", metricID(m)))
+							scrapeLog.Debugf("Completed %s: %v", metricID(m), result.Total)
+							if result.MissingFields > 0 {
+								scrapeLog.Debugf("Treated %d issues of %s without fields as empty", result.MissingFields, metricID(m))
+							}
+							if len(result.UnknownWeights) > 0 {
+								scrapeLog.Debugf("Used the default weight of %s for %s", metricID(m), strings.Join(result.UnknownWeights, ", "))
+							}
+							setPartial(m, result.Partial)
+							if result.Partial {
+								scrapeLog.Warnf("Stopped paginating %s after %d pages as it exceeded its query budget of %s", metricID(m), result.Pages, m.ParsedBudget)
+							}
+							if result.SkippedEpics > 0 {
+								scrapeLog.Warnf("Ignored %d epics of %s exceeding maxEpics %d", result.SkippedEpics, metricID(m), m.MaxEpics)
+							}
+							for key, projectErr := range result.ProjectErrors {
+								scrapeLog.WithError(projectErr).Warnf("Failed to count %s for project %s", metricID(m), key)
+								var scrapeErr *scrapeError
+								if errors.As(projectErr, &scrapeErr) {
+									recordError(m, scrapeErr.reason)
+								}
+							}
+						}
+						backoff = 0
+						if isInvalidJQL(err) {
+							backoff = invalidJQLBackoff - 1
+						}
+						setInvalidJQL(m, isInvalidJQL(err))
+						lastErrorReason = setLastError(m, lastErrorReason, classifyError(err))
+						return err
+					}
+					return nil
+				}
+				// Scrapes run in the background so that ticks arriving while a
+				// scrape is still running can be skipped instead of piling up.
+				// The result is buffered as nobody receives it anymore once the
+				// worker panicked.
+				done := make(chan error, 1)
+				running := false
+				run := func(forced bool) {
+					running = true
+					go func() {
+						inFlight.Lock()
+						defer inFlight.Unlock()
+						done <- recoverScrape(log, m, func() error {
+							return scrapeOnce(forced)
+						})
+					}()
+				}
+				// Connection failures are retried well before the next tick.
+				connRetry := newConnectionRetry(m.ParsedInterval)
+				var retry <-chan time.Time
+				// Triggers fired during a scrape result in a single additional
+				// scrape right afterwards.
+				pending := false
+				triggered := cfg.scrapeTrigger.wait()
+				run(false)
+			loop:
+				for {
+					// The heartbeat tells a wedged worker apart from a
+					// failing Jira.
+					recordTick(m)
+					select {
+					case <-timer.C:
+						if running {
+							skipScrape(m)
+							log.Debugf("Skipping %s as the previous scrape is still running", metricID(m))
+							continue
+						}
+						run(false)
+					case <-triggered:
+						triggered = cfg.scrapeTrigger.wait()
+						if running {
+							pending = true
+							continue
+						}
+						run(true)
+					case <-retry:
+						retry = nil
+						if !running {
+							run(false)
+						}
+					case err := <-done:
+						running = false
+						retry = nil
+						if isTransientConnectionError(err) {
+							if delay, ok := connRetry.next(); ok {
+								log.Debugf("Retrying %s in %s", metricID(m), delay)
+								retry = time.After(delay)
+							}
+						} else {
+							connRetry.reset()
+						}
+						if pending {
+							pending = false
+							run(true)
+						}
+					case <-ctx.Done():
+						// In-flight scrapes are not aborted on shutdown.
+						if running {
+							<-done
+						}
+						break loop
+					}
+				}
+				log.Infof("Stopping worker for %s", metricID(m))
+			})
+		}(idx, m)
+	}
+	wg.Wait()
+	stopClock()
+	<-clockDone
+}
+
+// updateGauge publishes the result of a scrape. For grouped metrics the
+// series of groups that were present in the previous scrape but are missing
+// now are removed. It returns the groups present after the update.
+func updateGauge(m MetricConfiguration, result scrapeResult, previous map[string]struct{}) map[string]struct{} {
+	if m.Gauge != nil {
+		m.Gauge.Set(result.Value)
+	}
+	if m.SubtaskGauge != nil {
+		m.SubtaskGauge.Set(result.Subtasks)
+	}
+	if m.GaugeVec == nil {
+		return nil
+	}
+	current := make(map[string]struct{}, len(result.Groups))
+	for group, count := range result.Groups {
+		m.GaugeVec.WithLabelValues(splitGroup(group)...).Set(count)
+		current[group] = struct{}{}
+	}
+	for group := range previous {
+		if _, ok := current[group]; !ok {
+			m.GaugeVec.DeleteLabelValues(splitGroup(group)...)
+		}
+	}
+	if m.SeriesCount != nil {
+		m.SeriesCount.Set(float64(len(current)))
+	}
+	return current
+}
+
+// gaugeLabel returns the name of the variable label of the given metric or
+// an empty string if the metric is exported as a plain gauge.
+func gaugeLabel(m MetricConfiguration) string {
+	switch {
+	case m.DistinctCount:
+		return ""
+	case m.Type == metricTypeResolutionTime:
+		return "aggregate"
+	case m.GroupBy != "":
+		return groupers[m.GroupBy].labelName(m)
+	case m.Type == metricTypeBoardColumns:
+		return columnLabel
+	case m.Type == metricTypeEpicProgress:
+		return epicLabel
+	case m.DiscoverProjects != nil:
+		return projectLabel
+	}
+	return ""
+}
+
+// emptySuffix returns the suffix of the gauge counting issues without any
+// group for grouped count metrics, or an empty string if there is none.
+func emptySuffix(m MetricConfiguration) string {
+	if m.GroupBy == "" || (m.Type != "" && m.Type != metricTypeCount && m.Type != metricTypeSprint) {
+		return ""
+	}
+	return groupers[m.GroupBy].emptySuffix
+}
+
+// sendStatsD sends the result of a scrape to StatsD. For grouped metrics the
+// group is added as a tag.
+func sendStatsD(client *statsdClient, m MetricConfiguration, result scrapeResult) {
+	if client == nil {
+		return
+	}
+	if m.Type == metricTypeSLA {
+		sendSLAStatsD(client, m, result)
+		return
+	}
+	if m.Type == metricTypeEpicProgress {
+		sendEpicStatsD(client, m, result)
+		return
+	}
+	label := gaugeLabel(m)
+	if label == "" {
+		client.gauge(m.Name, result.Value, m.Labels)
+		return
+	}
+	if suffix := emptySuffix(m); suffix != "" {
+		client.gauge(m.Name+"_"+suffix, result.Value, m.Labels)
+	}
+	for group, count := range result.Groups {
+		tags := make(map[string]string, len(m.Labels)+1)
+		for k, v := range m.Labels {
+			tags[k] = v
+		}
+		tags[label] = group
+		client.gauge(m.Name, count, tags)
+	}
+}
+
+// SetupGauges registers the gauges of all metrics with registry and the
+// exporter's own metrics like scrape errors with telemetry.
+func SetupGauges(registry prometheus.Registerer, telemetry prometheus.Registerer, metrics []MetricConfiguration) error {
+	scrapeErrors := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "jira_scrape_errors_total",
+		Help: "Number of failed scrapes per metric and reason",
+	}, []string{"metric", "reason"})
+	if err := telemetry.Register(scrapeErrors); err != nil {
+		return err
+	}
+	up := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "jira_up",
+		Help: "1 if the last scrape of the metric was successful, 0 otherwise",
+	}, []string{"name"})
+	if err := telemetry.Register(up); err != nil {
+		return err
+	}
+	lastError := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "jira_scrape_last_error_info",
+		Help: "Reason of the last failed scrape of a metric, absent if the last scrape was successful",
+	}, []string{"metric", "reason"})
+	if err := telemetry.Register(lastError); err != nil {
+		return err
+	}
+	invalidJQL := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "jira_invalid_jql",
+		Help: "1 if Jira rejected the JQL of the metric in the last scrape, 0 otherwise",
+	}, []string{"name"})
+	if err := telemetry.Register(invalidJQL); err != nil {
+		return err
+	}
+	endpoint := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "jira_scrape_endpoint_info",
+		Help: "Jira endpoint that served the last successful scrape of a metric",
+	}, []string{"metric", "endpoint"})
+	if err := telemetry.Register(endpoint); err != nil {
+		return err
+	}
+	skipped := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "jira_scrape_skipped_total",
+		Help: "Number of scrapes skipped because the previous scrape of the metric was still running",
+	}, []string{"metric"})
+	if err := telemetry.Register(skipped); err != nil {
+		return err
+	}
+	lastTick := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "jiravars_worker_last_tick_timestamp_seconds",
+		Help: "Time the worker of the metric last handled an event, regardless of the scrape's outcome",
+	}, []string{"metric"})
+	if err := telemetry.Register(lastTick); err != nil {
+		return err
+	}
+	seriesCount := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "jira_metric_series_count",
+		Help: "Number of series of the grouped metric after its last successful scrape",
+	}, []string{"name"})
+	if err := telemetry.Register(seriesCount); err != nil {
+		return err
+	}
+	partial := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "jira_partial_result",
+		Help: "Whether the last successful scrape of the metric stopped paginating after exceeding its query budget",
+	}, []string{"name"})
+	if err := telemetry.Register(partial); err != nil {
+		return err
+	}
+	seriesLimitHit := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "jira_series_limit_hit",
+		Help: "Whether the last successful scrape of the grouped metric had more groups than its maxSeries",
+	}, []string{"metric"})
+	if err := telemetry.Register(seriesLimitHit); err != nil {
+		return err
+	}
+	interval := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "jira_scrape_interval_seconds",
+		Help: "Configured interval between two scrapes of the metric",
+	}, []string{"name"})
+	if err := telemetry.Register(interval); err != nil {
+		return err
+	}
+	matrixVecs := make(map[string]*prometheus.GaugeVec)
+	for i := 0; i < len(metrics); i++ {
+		metrics[i].Errors = scrapeErrors
+		metrics[i].LastError = lastError
+		metrics[i].Endpoint = endpoint
+		metrics[i].Skipped = skipped.WithLabelValues(metricID(metrics[i]))
+		metrics[i].LastTick = lastTick.WithLabelValues(metricID(metrics[i]))
+		if gaugeLabel(metrics[i]) != "" {
+			metrics[i].SeriesCount = seriesCount.WithLabelValues(metricID(metrics[i]))
+			metrics[i].SeriesCount.Set(0)
+		}
+		if metrics[i].GroupBy != "" {
+			metrics[i].SeriesLimitHit = seriesLimitHit.WithLabelValues(metricID(metrics[i]))
+			metrics[i].SeriesLimitHit.Set(0)
+		}
+		metrics[i].Up = up.WithLabelValues(metricID(metrics[i]))
+		metrics[i].Up.Set(0)
+		metrics[i].InvalidJQL = invalidJQL.WithLabelValues(metricID(metrics[i]))
+		metrics[i].InvalidJQL.Set(0)
+		metrics[i].Partial = partial.WithLabelValues(metricID(metrics[i]))
+		metrics[i].Partial.Set(0)
+		interval.WithLabelValues(metricID(metrics[i])).Set(metrics[i].ParsedInterval.Seconds())
+		// Metrics disabled because of their JQL only report that.
+		if metrics[i].InvalidQuery != nil {
+			continue
+		}
+		opts := prometheus.GaugeOpts{
+			Name:        fmt.Sprintf("jira_%s", metrics[i].Name),
+			ConstLabels: metrics[i].Labels,
+			Help:        metrics[i].Help,
+		}
+		if metrics[i].GroupBy != "" {
+			if _, err := lookupGrouper(metrics[i].GroupBy); err != nil {
+				return err
+			}
+		}
+		if metrics[i].Subtasks == subtasksSeparate {
+			subtaskOpts := opts
+			subtaskOpts.Name = fmt.Sprintf("%s_subtasks", opts.Name)
+			subtaskOpts.Help = fmt.Sprintf("Sub-tasks not counted in %s", opts.Name)
+			metrics[i].SubtaskGauge = prometheus.NewGauge(subtaskOpts)
+			if err := registry.Register(metrics[i].SubtaskGauge); err != nil {
+				return err
+			}
+		}
+		if metrics[i].Type == metricTypeSLA {
+			if err := setupSLAGauges(registry, &metrics[i], opts); err != nil {
+				return err
+			}
+			continue
+		}
+		if metrics[i].Type == metricTypeEpicProgress {
+			if err := setupEpicGauges(registry, &metrics[i], opts); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(metrics[i].MatrixValues) > 0 {
+			if err := setupMatrixGauges(registry, matrixVecs, &metrics[i], opts); err != nil {
+				return err
+			}
+			continue
+		}
+		if label := gaugeLabel(metrics[i]); label != "" {
+			metrics[i].GaugeVec = prometheus.NewGaugeVec(opts, []string{label})
+			if err := registry.Register(metrics[i].GaugeVec); err != nil {
+				return err
+			}
+			if suffix := emptySuffix(metrics[i]); suffix != "" {
+				emptyOpts := opts
+				emptyOpts.Name = fmt.Sprintf("%s_%s", opts.Name, suffix)
+				metrics[i].Gauge = prometheus.NewGauge(emptyOpts)
+				if err := registry.Register(metrics[i].Gauge); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		metrics[i].Gauge = prometheus.NewGauge(opts)
+		if err := registry.Register(metrics[i].Gauge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// metricsHandler serves the gathered metrics in the Prometheus text format or
+// as OpenMetrics if requested by the client.
+func metricsHandler(registry prometheus.Registerer, gatherer prometheus.Gatherer) http.Handler {
+	return promhttp.InstrumentMetricHandler(registry, promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	}))
+}
+
+// validateHTTPPath checks the path given using the flag of the given name.
+func validateHTTPPath(flag string, path string) error {
+	if !strings.HasPrefix(path, "/") {
+		return errors.Errorf("--%s %q must start with /", flag, path)
+	}
+	return nil
+}
+
+// routePrefix returns the prefix of all routes without a trailing slash.
+// Without an explicit prefix the path of the external URL is used, which is
+// where a reverse proxy forwards the requests from.
+func routePrefix(prefix string, externalURL string) (string, error) {
+	if prefix == "" && externalURL != "" {
+		u, err := url.Parse(externalURL)
+		if err != nil {
+			return "", errors.Wrap(err, "invalid --web.external-url")
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return "", errors.Errorf("--web.external-url %q must be an absolute URL", externalURL)
+		}
+		prefix = u.Path
+	}
+	if prefix == "" {
+		return "", nil
+	}
+	if err := validateHTTPPath("web.route-prefix", prefix); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(prefix, "/"), nil
+}
+
+// newRegistries creates the registry of the Jira metrics and the one of the
+// exporter's own metrics including the Go runtime. If the telemetry is served
+// on metricsPath as well, both are the same registry.
+func newRegistries(metricsPath string, telemetryPath string) (*prometheus.Registry, *prometheus.Registry) {
+	registry := prometheus.NewRegistry()
+	telemetry := registry
+	if telemetryPath != metricsPath {
+		telemetry = prometheus.NewRegistry()
+	}
+	startTime := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "jiravars_start_time_seconds",
+		Help: "Start time of the exporter since unix epoch in seconds",
+	})
+	startTime.SetToCurrentTime()
+	info := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "jira_exporter_info",
+		Help: "Version of the exporter and the Go runtime it was built with, always 1",
+		ConstLabels: prometheus.Labels{
+			"version":   version,
+			"goversion": runtime.Version(),
+			"platform":  runtime.GOOS + "/" + runtime.GOARCH,
+		},
+	})
+	info.Set(1)
+	telemetry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		startTime,
+		info,
+	)
+	return registry, telemetry
+}
+
+// newMux serves the metrics of registry on metricsPath and those of
+// telemetry on telemetryPath.
+func newMux(registry *prometheus.Registry, telemetry *prometheus.Registry, metricsPath string, telemetryPath string) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, metricsHandler(telemetry, registry))
+	if registry != telemetry {
+		mux.Handle(telemetryPath, metricsHandler(telemetry, telemetry))
+	}
+	return mux
+}
+
+// allGatherers returns a gatherer collecting the metrics of both registries.
+func allGatherers(registry *prometheus.Registry, telemetry *prometheus.Registry) prometheus.Gatherer {
+	if registry == telemetry {
+		return registry
+	}
+	return prometheus.Gatherers{registry, telemetry}
+}
+
+// Options are the command line options of the standalone exporter.
+type Options struct {
+	ConfigFile         string
+	Addrs              []string
+	MetricsPath        string
+	TelemetryPath      string
+	Verbose            bool
+	PrintURLsOnly      bool
+	PrintSampleConfig  bool
+	AllowFastIntervals bool
+	OTLPEndpoint       string
+	LogFile            string
+	LogMaxSize         int64
+	LogMaxBackups      int
+	StartupDeadline    time.Duration
+	ValidateQueries    string
+	TextfileOutput     string
+	WebRoutePrefix     string
+	WebExternalURL     string
+}
+
+// Main runs the standalone exporter with the given options until it receives
+// SIGINT or SIGTERM. It exits the process on fatal errors.
+func Main(opts Options) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	log := logrus.New()
+	metricsPath := opts.MetricsPath
+	telemetryPath := opts.TelemetryPath
+
+	// Credentials are only known once the configuration has been loaded,
+	// URLs are redacted right away.
+	redactor := &redactor{}
+	log.SetFormatter(&redactingFormatter{Formatter: log.Formatter, redactor: redactor})
+
+	if opts.Verbose {
+		log.SetLevel(logrus.DebugLevel)
+	} else {
+		log.SetLevel(logrus.InfoLevel)
+	}
+
+	if opts.LogFile != "" {
+		if opts.LogMaxSize < 0 || opts.LogMaxBackups < 0 {
+			log.Fatal("--log-max-size and --log-max-backups must not be negative")
+		}
+		out, err := openRotatingFile(opts.LogFile, opts.LogMaxSize*1024*1024, opts.LogMaxBackups)
+		if err != nil {
+			log.WithError(err).Fatalf("Failed to open log file %s", opts.LogFile)
+		}
+		defer out.Close()
+		log.SetOutput(out)
+		// logrotate and friends send SIGHUP after moving the file away.
+		reopenChan := make(chan os.Signal, 1)
+		signal.Notify(reopenChan, syscall.SIGHUP)
+		go func() {
+			for range reopenChan {
+				if err := out.Reopen(); err != nil {
+					log.WithError(err).Error("Failed to reopen log file")
+				}
+			}
+		}()
+	}
+
+	if err := validateHTTPPath("metrics-path", metricsPath); err != nil {
+		log.Fatal(err)
+	}
+	if err := validateHTTPPath("telemetry-path", telemetryPath); err != nil {
+		log.Fatal(err)
+	}
+	prefix, err := routePrefix(opts.WebRoutePrefix, opts.WebExternalURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if opts.WebExternalURL != "" {
+		log.Infof("Metrics are available at %s%s", strings.TrimRight(opts.WebExternalURL, "/"), metricsPath)
+	}
+	metricsPath = prefix + metricsPath
+	telemetryPath = prefix + telemetryPath
+
+	switch opts.ValidateQueries {
+	case ValidateQueriesOff, ValidateQueriesWarn, ValidateQueriesStrict, ValidateQueriesDisable:
+	default:
+		log.Fatalf("Unsupported --validate-queries %q", opts.ValidateQueries)
+	}
+
+	if opts.PrintSampleConfig {
+		fmt.Print(sampleConfig)
+		return
+	}
+
+	if opts.ConfigFile == "" {
+		log.Fatal("Please specify a config file using --config CONFIG_FILE")
+	}
+
+	cfg, err := LoadConfiguration(opts.ConfigFile, opts.AllowFastIntervals)
+	if err != nil {
+		log.WithError(err).Fatalf("Failed to load config from %s", opts.ConfigFile)
+	}
+
+	if opts.PrintURLsOnly {
+		if err := printURLs(os.Stdout, cfg); err != nil {
+			log.WithError(err).Fatal("Failed to print URLs")
+		}
+		return
+	}
+
+	redactor.addSecrets(cfg)
+
+	if err := checkPasswords(cfg); err != nil {
+		log.WithError(err).Fatal("Missing credentials")
+	}
+
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to setup HTTP client")
+	}
+
+	shutdownTracing, err := setupTracing(ctx, opts.OTLPEndpoint)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to setup tracing")
+	}
+	defer shutdownTracing(context.Background())
+	if tracingEnabled(opts.OTLPEndpoint) {
+		httpClient.Transport = otelhttp.NewTransport(httpClient.Transport)
+	}
+
+	// Queries are validated before the gauges are set up, so that metrics
+	// disabled because of their JQL don't export a value.
+	if opts.ValidateQueries != ValidateQueriesOff {
+		invalid := validateQueries(ctx, log, cfg, httpClient)
+		for _, q := range invalid {
+			log.WithError(q.err).Warn("Jira rejected a query")
+		}
+		if len(invalid) > 0 && opts.ValidateQueries == ValidateQueriesStrict {
+			log.Fatalf("%d of %d queries are invalid", len(invalid), len(cfg.Metrics))
+		}
+		if len(invalid) > 0 && opts.ValidateQueries == ValidateQueriesDisable {
+			log.Warnf("Disabling %d of %d metrics with invalid queries", len(invalid), len(cfg.Metrics))
+			markInvalidQueries(cfg, invalid)
+		}
+	}
+
+	registry, telemetry := newRegistries(metricsPath, telemetryPath)
+	if err := Setup(log, registry, telemetry, cfg); err != nil {
+		log.WithError(err).Fatal("Failed to setup the exporter")
+	}
+	if opts.StartupDeadline > 0 {
+		go warnPendingAfter(ctx, log, cfg.scrapeStates, opts.StartupDeadline)
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	triggerChan := make(chan os.Signal, 1)
+	signal.Notify(triggerChan, syscall.SIGUSR1)
+	go func() {
+		for range triggerChan {
+			log.Info("Received SIGUSR1, scraping all metrics")
+			cfg.scrapeTrigger.fire()
+		}
+	}()
+	probe, err := setupProbe(telemetry, cfg, httpClient)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to setup Jira probe")
+	}
+
+	cfg.statsdClient, err = newStatsDClient(log, cfg.StatsD)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to setup StatsD client")
+	}
+	defer cfg.statsdClient.Close()
+
+	shutdownOTLPMetrics, err := setupOTLPMetrics(ctx, log, cfg.OTLP, allGatherers(registry, telemetry), telemetry)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to setup OTLP metrics export")
+	}
+	defer shutdownOTLPMetrics(context.Background())
+
+	handler := newMux(registry, telemetry, metricsPath, telemetryPath)
+	// With systemd's socket activation the listener is inherited instead.
+	listener, err := systemdListener()
+	if err != nil {
+		log.WithError(err).Fatal("Failed to setup listener")
+	}
+	var listeners []net.Listener
+	if listener != nil {
+		listeners = append(listeners, listener)
+	} else {
+		for _, addr := range opts.Addrs {
+			listener, err := net.Listen("tcp", addr)
+			if err != nil {
+				log.WithError(err).Fatalf("Failed to listen on %s", addr)
+			}
+			listeners = append(listeners, listener)
+		}
+	}
+	httpServers := make([]*http.Server, 0, len(listeners))
+	for range listeners {
+		httpServers = append(httpServers, &http.Server{Handler: handler})
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(httpServers) + 2)
+	go func() {
+		defer wg.Done()
+		// A failing server cancels the context, which stops all others as
+		// well.
+		select {
+		case <-sigChan:
+			log.Info("Shutting down...")
+		case <-ctx.Done():
+		}
+		if err := sdNotify("STOPPING=1"); err != nil {
+			log.WithError(err).Warn("Failed to notify systemd")
+		}
+		for _, httpServer := range httpServers {
+			httpServer.Close()
+		}
+		cancel()
+	}()
+
+	go func() {
+		defer wg.Done()
+		NewScraper(log, cfg, httpClient).Run(ctx)
+	}()
+
+	if opts.TextfileOutput != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			writeTextfiles(ctx, log, opts.TextfileOutput, allGatherers(registry, telemetry), textfileInterval(cfg.Metrics))
+		}()
+	}
+
+	// The probe runs independently of the metric workers.
+	if probe != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			probe.run(ctx, log)
+		}()
+	}
+
+	for i, httpServer := range httpServers {
+		go func(httpServer *http.Server, listener net.Listener) {
+			defer wg.Done()
+			log.Infof("Starting server on %s", listener.Addr())
+			if err := httpServer.Serve(listener); err != nil {
+				cancel()
+				log.WithError(err).Error("Server stopped")
+			}
+		}(httpServer, listeners[i])
+	}
+	// Connections are queued by the listeners until the servers accept
+	// them, so it is safe to report readiness right away.
+	if err := sdNotify("READY=1"); err != nil {
+		log.WithError(err).Warn("Failed to notify systemd")
+	}
+
+	wg.Wait()
+}
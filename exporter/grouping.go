@@ -0,0 +1,396 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// searchPageSize is the number of issues requested per page for metrics
+// that have to look at individual issues.
+const searchPageSize = 100
+
+// issue contains the subset of an issue's fields that can be used for
+// grouping. Only the fields requested for a metric are populated.
+type issue struct {
+	Key    string      `json:"key"`
+	Fields issueFields `json:"fields"`
+	// MissingFields is set if the response contained no fields for the
+	// issue. Such issues are treated as having no values at all.
+	MissingFields bool `json:"-"`
+	// RawFields are the fields as returned by Jira, see evaluatePath.
+	RawFields json.RawMessage `json:"-"`
+}
+
+func (i *issue) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Key    string          `json:"key"`
+		Fields json.RawMessage `json:"fields"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	i.Key = raw.Key
+	i.RawFields = raw.Fields
+	if len(raw.Fields) == 0 || bytes.Equal(raw.Fields, []byte("null")) {
+		i.MissingFields = true
+		return nil
+	}
+	return json.Unmarshal(raw.Fields, &i.Fields)
+}
+
+type issueFields struct {
+	Status      *issueStatus `json:"status"`
+	FixVersions []namedValue `json:"fixVersions"`
+	Components  []namedValue `json:"components"`
+	Assignee    *user        `json:"assignee"`
+	Reporter    *user        `json:"reporter"`
+	Labels      []string     `json:"labels"`
+	Priority    *namedValue  `json:"priority"`
+	Parent      *issueRef    `json:"parent"`
+	Resolution  *namedValue  `json:"resolution"`
+	IssueType   *issueType   `json:"issuetype"`
+	// Created and ResolutionDate use Jira's timestamp format, see
+	// parseJiraTime.
+	Created        string `json:"created"`
+	ResolutionDate string `json:"resolutiondate"`
+	// TimeSpent is the aggregated time logged on an issue in seconds. It
+	// is null if no work has been logged.
+	TimeSpent *int64 `json:"timespent"`
+	// Custom contains the raw values of all custom fields present.
+	Custom map[string]json.RawMessage `json:"-"`
+}
+
+const customFieldPrefix = "customfield_"
+
+func (f *issueFields) UnmarshalJSON(data []byte) error {
+	type plain issueFields
+	if err := json.Unmarshal(data, (*plain)(f)); err != nil {
+		return err
+	}
+	// Only decode the fields a second time if there are custom fields at
+	// all.
+	if !bytes.Contains(data, []byte(customFieldPrefix)) {
+		return nil
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for key, value := range raw {
+		if !strings.HasPrefix(key, customFieldPrefix) {
+			continue
+		}
+		if f.Custom == nil {
+			f.Custom = make(map[string]json.RawMessage)
+		}
+		f.Custom[key] = value
+	}
+	return nil
+}
+
+// user is a Jira user as referenced by fields like assignee. Jira Cloud
+// identifies users by their accountId, Jira Server by their name.
+type user struct {
+	AccountID   string `json:"accountId"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// issueRef references another issue like the parent.
+type issueRef struct {
+	Key string `json:"key"`
+}
+
+// namedValue is used for all fields referencing other entities by name like
+// versions or components.
+type namedValue struct {
+	Name string `json:"name"`
+}
+
+type issueStatus struct {
+	Name           string `json:"name"`
+	StatusCategory struct {
+		Key  string `json:"key"`
+		Name string `json:"name"`
+	} `json:"statusCategory"`
+}
+
+// grouper describes how issues are split into label values for a groupBy
+// setting.
+type grouper struct {
+	// field is the Jira field that has to be requested.
+	field string
+	// fieldFunc, if set, returns the field for metrics that configure it
+	// themselves.
+	fieldFunc func(MetricConfiguration) string
+	// label is the name of the Prometheus label holding the group.
+	label string
+	// labelFunc, if set, returns the label for metrics that configure it
+	// themselves.
+	labelFunc func(MetricConfiguration) string
+	// values returns the groups an issue belongs to. An issue can be part
+	// of multiple groups or of none at all.
+	values func(issue, MetricConfiguration) []string
+	// emptyGroup is the default group of issues without any value. Such
+	// issues are not counted if empty.
+	emptyGroup string
+	// emptySuffix, if set, counts issues without any value in a separate
+	// jira_<name>_<emptySuffix> gauge instead.
+	emptySuffix string
+}
+
+var groupers = map[string]grouper{
+	"statusCategory": {
+		field:  "status",
+		label:  "status_category",
+		values: statusCategoryValues,
+	},
+	"fixVersions": {
+		field: "fixVersions",
+		label: "fix_version",
+		values: func(i issue, _ MetricConfiguration) []string {
+			return uniqueNames(i.Fields.FixVersions)
+		},
+		emptyGroup: "unscheduled",
+	},
+	"components": {
+		field: "components",
+		label: "component",
+		values: func(i issue, _ MetricConfiguration) []string {
+			return uniqueNames(i.Fields.Components)
+		},
+		emptyGroup: "none",
+	},
+	"assignee": {
+		field:      "assignee",
+		label:      "assignee",
+		values:     assigneeValues,
+		emptyGroup: "unassigned",
+	},
+	"reporter": {
+		field:      "reporter",
+		label:      "reporter",
+		values:     reporterValues,
+		emptyGroup: "none",
+	},
+	"priority": {
+		field: "priority",
+		label: "priority",
+		values: func(i issue, _ MetricConfiguration) []string {
+			if i.Fields.Priority == nil || i.Fields.Priority.Name == "" {
+				return nil
+			}
+			return []string{i.Fields.Priority.Name}
+		},
+		emptyGroup: "none",
+	},
+	"epic": {
+		field: "parent",
+		fieldFunc: func(m MetricConfiguration) string {
+			if m.EpicField != "" {
+				return m.EpicField
+			}
+			return "parent"
+		},
+		label:      "epic",
+		values:     epicValues,
+		emptyGroup: "none",
+	},
+	"resolutionSplit": {
+		field:  "resolution",
+		label:  "resolution",
+		values: resolutionSplitValues,
+	},
+	"labels": {
+		field: "labels",
+		label: "label",
+		values: func(i issue, _ MetricConfiguration) []string {
+			return uniqueStrings(i.Fields.Labels)
+		},
+		emptySuffix: "unlabeled",
+	},
+	"path": {
+		fieldFunc: func(m MetricConfiguration) string {
+			if m.Path == nil {
+				return ""
+			}
+			return pathSegments(m.Path.Expression)[0]
+		},
+		labelFunc: func(m MetricConfiguration) string {
+			if m.Path == nil || m.Path.Label == "" {
+				return defaultPathLabel
+			}
+			return m.Path.Label
+		},
+		values:     pathValues,
+		emptyGroup: "none",
+	},
+}
+
+// requestedField returns the Jira field that has to be requested for the
+// given metric.
+func (g grouper) requestedField(m MetricConfiguration) string {
+	if g.fieldFunc != nil {
+		return g.fieldFunc(m)
+	}
+	return g.field
+}
+
+// labelName returns the name of the label holding the group for the given
+// metric.
+func (g grouper) labelName(m MetricConfiguration) string {
+	if g.labelFunc != nil {
+		return g.labelFunc(m)
+	}
+	return g.label
+}
+
+// lookupGrouper returns the grouper for the given groupBy setting.
+func lookupGrouper(groupBy string) (grouper, error) {
+	g, ok := groupers[groupBy]
+	if !ok {
+		names := make([]string, 0, len(groupers))
+		for name := range groupers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return g, errors.Errorf("unsupported groupBy %q (supported: %s)", groupBy, strings.Join(names, ", "))
+	}
+	return g, nil
+}
+
+// statusCategoryKeys maps the keys of Jira's built-in status categories onto
+// stable label values. The keys are used instead of the names as the latter
+// are localized.
+var statusCategoryKeys = map[string]string{
+	"new":           "todo",
+	"indeterminate": "inprogress",
+	"done":          "done",
+}
+
+func statusCategoryValues(i issue, _ MetricConfiguration) []string {
+	if i.Fields.Status == nil {
+		return nil
+	}
+	category := i.Fields.Status.StatusCategory
+	if value, ok := statusCategoryKeys[category.Key]; ok {
+		return []string{value}
+	}
+	if category.Name == "" {
+		return nil
+	}
+	return []string{strings.ToLower(strings.ReplaceAll(category.Name, " ", ""))}
+}
+
+const (
+	assigneeDisplayName = "displayName"
+	assigneeAccountID   = "accountId"
+)
+
+func assigneeValues(i issue, m MetricConfiguration) []string {
+	return userValues(i.Fields.Assignee, m)
+}
+
+func reporterValues(i issue, m MetricConfiguration) []string {
+	return userValues(i.Fields.Reporter, m)
+}
+
+// userValues uses the identifier of the user selected by
+// assigneeIdentifier as group. Jira Server doesn't know account IDs, so the
+// user name is used instead there. Display names hidden by the user's
+// privacy settings fall back to the account ID.
+func userValues(a *user, m MetricConfiguration) []string {
+	if a == nil {
+		return nil
+	}
+	id := a.AccountID
+	if id == "" {
+		id = a.Name
+	}
+	value := a.DisplayName
+	if m.AssigneeIdentifier == assigneeAccountID || value == "" {
+		value = id
+	}
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+// resolutionSplitValues splits issues by whether their resolution is set,
+// which allows counting open and resolved issues using a single JQL.
+func resolutionSplitValues(i issue, _ MetricConfiguration) []string {
+	if i.Fields.Resolution == nil {
+		return []string{"unresolved"}
+	}
+	return []string{"resolved"}
+}
+
+// epicValues uses the key of the issue's epic. Older Jira versions link
+// epics using a custom field (epicField) containing the key, newer ones use
+// the parent.
+func epicValues(i issue, m MetricConfiguration) []string {
+	if m.EpicField == "" {
+		if i.Fields.Parent == nil || i.Fields.Parent.Key == "" {
+			return nil
+		}
+		return []string{i.Fields.Parent.Key}
+	}
+	var key string
+	if err := json.Unmarshal(i.Fields.Custom[m.EpicField], &key); err != nil || key == "" {
+		return nil
+	}
+	return []string{key}
+}
+
+// groupValues returns the groups of an issue for the given metric, falling
+// back to the metric's (or grouper's) empty group. Values are normalized
+// first, values rejected by the metric's value filter are then folded into
+// otherGroup. Finally the values are sanitized, so that values only differing
+// in characters replaced by the sanitization count once.
+func groupValues(g grouper, i issue, m MetricConfiguration) []string {
+	values := normalizeValues(m.Normalize, g.values(i, m))
+	if len(values) > 0 {
+		return sanitizeLabelValues(m.LabelSanitization, m.valueFilter.apply(values))
+	}
+	emptyGroup := g.emptyGroup
+	if m.EmptyGroup != "" {
+		emptyGroup = m.EmptyGroup
+	}
+	if emptyGroup == "" {
+		return nil
+	}
+	return []string{sanitizeLabelValue(m.LabelSanitization, emptyGroup)}
+}
+
+// uniqueStrings returns the distinct, non-empty values in their original
+// order.
+func uniqueStrings(values []string) []string {
+	result := make([]string, 0, len(values))
+	seen := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		result = append(result, v)
+	}
+	return result
+}
+
+// uniqueNames returns the distinct, non-empty names of the given values in
+// their original order.
+func uniqueNames(values []namedValue) []string {
+	names := make([]string, 0, len(values))
+	for _, v := range values {
+		names = append(names, v.Name)
+	}
+	return uniqueStrings(names)
+}
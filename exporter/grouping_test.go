@@ -0,0 +1,224 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusCategoryValues(t *testing.T) {
+	withCategory := func(key, name string) issue {
+		i := issue{Fields: issueFields{Status: &issueStatus{}}}
+		i.Fields.Status.StatusCategory.Key = key
+		i.Fields.Status.StatusCategory.Name = name
+		return i
+	}
+	require.Equal(t, []string{"todo"}, statusCategoryValues(withCategory("new", "Zu erledigen"), MetricConfiguration{}))
+	require.Equal(t, []string{"inprogress"}, statusCategoryValues(withCategory("indeterminate", "In Progress"), MetricConfiguration{}))
+	require.Equal(t, []string{"done"}, statusCategoryValues(withCategory("done", "Done"), MetricConfiguration{}))
+	require.Equal(t, []string{"nocategory"}, statusCategoryValues(withCategory("undefined", "No Category"), MetricConfiguration{}))
+	require.Nil(t, statusCategoryValues(issue{}, MetricConfiguration{}))
+}
+
+func TestLookupGrouper(t *testing.T) {
+	g, err := lookupGrouper("statusCategory")
+	require.NoError(t, err)
+	require.Equal(t, "status", g.field)
+	_, err = lookupGrouper("unknown")
+	require.Error(t, err)
+}
+
+func TestFixVersionValues(t *testing.T) {
+	g, err := lookupGrouper("fixVersions")
+	require.NoError(t, err)
+	counts := map[string]int{}
+	for _, i := range []issue{
+		{Fields: issueFields{FixVersions: []namedValue{{Name: "1.0"}, {Name: "1.1"}}}},
+		{Fields: issueFields{FixVersions: []namedValue{{Name: "1.1"}, {Name: "1.1"}}}},
+		{Fields: issueFields{}},
+	} {
+		for _, v := range groupValues(g, i, MetricConfiguration{}) {
+			counts[v]++
+		}
+	}
+	require.Equal(t, map[string]int{"1.0": 1, "1.1": 2, "unscheduled": 1}, counts)
+}
+
+func TestAssigneeValues(t *testing.T) {
+	g, err := lookupGrouper("assignee")
+	require.NoError(t, err)
+	cloud := issue{Fields: issueFields{Assignee: &user{AccountID: "5b10a2844c20165700ede21g", DisplayName: "Jane Doe"}}}
+	server := issue{Fields: issueFields{Assignee: &user{Name: "jdoe", DisplayName: "Jane Doe"}}}
+	hidden := issue{Fields: issueFields{Assignee: &user{AccountID: "5b10ac8d82e05b22cc7d4ef5"}}}
+	unassigned := issue{}
+
+	byName := MetricConfiguration{}
+	require.Equal(t, []string{"Jane Doe"}, groupValues(g, cloud, byName))
+	require.Equal(t, []string{"Jane Doe"}, groupValues(g, server, byName))
+
+	byAccount := MetricConfiguration{AssigneeIdentifier: assigneeAccountID}
+	require.Equal(t, []string{"5b10a2844c20165700ede21g"}, groupValues(g, cloud, byAccount))
+	require.Equal(t, []string{"jdoe"}, groupValues(g, server, byAccount))
+
+	require.Equal(t, []string{"5b10ac8d82e05b22cc7d4ef5"}, groupValues(g, hidden, byName))
+	require.Equal(t, []string{"unassigned"}, groupValues(g, unassigned, byName))
+	require.Equal(t, []string{"nobody"}, groupValues(g, unassigned, MetricConfiguration{EmptyGroup: "nobody"}))
+}
+
+func TestReporterValues(t *testing.T) {
+	g, err := lookupGrouper("reporter")
+	require.NoError(t, err)
+	counts := map[string]int{}
+	for _, i := range []issue{
+		{Fields: issueFields{Reporter: &user{AccountID: "5b10a2844c20165700ede21g", DisplayName: "Jane Doe"}, Assignee: &user{DisplayName: "John Roe"}}},
+		{Fields: issueFields{Reporter: &user{AccountID: "5b10ac8d82e05b22cc7d4ef5", DisplayName: "Max Mustermann"}}},
+		{Fields: issueFields{Reporter: &user{AccountID: "5b10a2844c20165700ede21g", DisplayName: "Jane Doe"}}},
+		{},
+	} {
+		for _, v := range groupValues(g, i, MetricConfiguration{}) {
+			counts[v]++
+		}
+	}
+	require.Equal(t, map[string]int{"Jane Doe": 2, "Max Mustermann": 1, "none": 1}, counts)
+
+	byAccount := MetricConfiguration{AssigneeIdentifier: assigneeAccountID}
+	require.Equal(t, []string{"5b10ac8d82e05b22cc7d4ef5"}, groupValues(g, issue{Fields: issueFields{Reporter: &user{AccountID: "5b10ac8d82e05b22cc7d4ef5", DisplayName: "Max Mustermann"}}}, byAccount))
+}
+
+func TestPriorityValues(t *testing.T) {
+	g, err := lookupGrouper("priority")
+	require.NoError(t, err)
+	counts := map[string]int{}
+	for _, i := range []issue{
+		{Fields: issueFields{Priority: &namedValue{Name: "Blocker"}}},
+		{Fields: issueFields{Priority: &namedValue{Name: "Major"}}},
+		{Fields: issueFields{Priority: &namedValue{Name: "Blocker"}}},
+		{Fields: issueFields{}},
+	} {
+		for _, v := range groupValues(g, i, MetricConfiguration{}) {
+			counts[v]++
+		}
+	}
+	require.Equal(t, map[string]int{"Blocker": 2, "Major": 1, "none": 1}, counts)
+	require.Equal(t, []string{"unset"}, groupValues(g, issue{}, MetricConfiguration{EmptyGroup: "unset"}))
+}
+
+func TestEpicValues(t *testing.T) {
+	g, err := lookupGrouper("epic")
+	require.NoError(t, err)
+	decode := func(data string) issue {
+		var i issue
+		require.NoError(t, json.Unmarshal([]byte(data), &i))
+		return i
+	}
+
+	byParent := MetricConfiguration{GroupBy: "epic"}
+	require.Equal(t, "parent", searchFields(byParent))
+	require.Equal(t, []string{"ABC-1"}, groupValues(g, decode(`{"fields": {"parent": {"key": "ABC-1", "fields": {"summary": "Epic"}}}}`), byParent))
+	require.Equal(t, []string{"none"}, groupValues(g, decode(`{"fields": {"parent": null}}`), byParent))
+
+	byCustomField := MetricConfiguration{GroupBy: "epic", EpicField: "customfield_10014"}
+	require.Equal(t, "customfield_10014", searchFields(byCustomField))
+	require.Equal(t, []string{"ABC-2"}, groupValues(g, decode(`{"fields": {"customfield_10014": "ABC-2"}}`), byCustomField))
+	require.Equal(t, []string{"none"}, groupValues(g, decode(`{"fields": {"customfield_10014": null}}`), byCustomField))
+	require.Equal(t, []string{"none"}, groupValues(g, decode(`{"fields": {}}`), byCustomField))
+}
+
+func TestGroupByLabels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"total": 3, "issues": [
+			{"fields": {"labels": ["backend", "urgent", "backend"]}},
+			{"fields": {"labels": ["backend"]}},
+			{"fields": {"labels": []}}
+		]}`)
+	}))
+	defer srv.Close()
+	reg := prometheus.NewRegistry()
+	metrics := []MetricConfiguration{{Name: "issues", Help: "test", JQL: "project = TEST", GroupBy: "labels"}}
+	require.NoError(t, SetupGauges(reg, reg, metrics))
+	s := newEndpointScraper(&Configuration{BaseURL: srv.URL}, &http.Client{})
+	result, err := s.scrape(context.Background(), metrics[0])
+	require.NoError(t, err)
+	updateGauge(metrics[0], result, nil)
+
+	require.Equal(t, float64(2), testutil.ToFloat64(metrics[0].GaugeVec.WithLabelValues("backend")))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics[0].GaugeVec.WithLabelValues("urgent")))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics[0].Gauge))
+	// Issues with multiple labels are counted once per label, so the sum of
+	// all series exceeds the number of labeled issues.
+	sum := 0.0
+	for _, v := range result.Groups {
+		sum += v
+	}
+	require.Equal(t, float64(3), sum)
+	require.Greater(t, sum, float64(result.Total)-result.Value)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	names := []string{}
+	for _, f := range families {
+		names = append(names, f.GetName())
+	}
+	require.Contains(t, names, "jira_issues_unlabeled")
+}
+
+func TestResolutionSplit(t *testing.T) {
+	var fields string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fields = r.URL.Query().Get("fields")
+		fmt.Fprint(w, `{"total": 4, "issues": [
+			{"fields": {"resolution": {"name": "Fixed"}}},
+			{"fields": {"resolution": null}},
+			{"fields": {"resolution": {"name": "Won't Do"}}},
+			{"fields": {}}
+		]}`)
+	}))
+	defer srv.Close()
+	s := newEndpointScraper(&Configuration{BaseURL: srv.URL}, &http.Client{})
+	result, err := s.scrape(context.Background(), MetricConfiguration{Name: "issues", JQL: "project = TEST", GroupBy: "resolutionSplit"})
+	require.NoError(t, err)
+	require.Equal(t, "resolution", fields)
+	require.Equal(t, map[string]float64{"resolved": 2, "unresolved": 2}, result.Groups)
+}
+
+func TestAllFields(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "*all", r.URL.Query().Get("fields"))
+		fmt.Fprint(w, `{"total": 3, "issues": [
+			{"key": "A-1", "fields": {"summary": "One", "description": "Long text", "labels": ["x"], "customfield_10014": "A-100", "customfield_10020": [{"id": 5}]}},
+			{"key": "A-2", "fields": {"summary": "Two", "customfield_10014": "A-100"}},
+			{"key": "A-3", "fields": {"summary": "Three", "customfield_10014": null}}
+		]}`)
+	}))
+	defer srv.Close()
+	cfg, err := LoadConfiguration(writeConfig(t, fmt.Sprintf(`
+baseURL: %s
+metrics:
+  - name: issues_per_epic
+    jql: project = A
+    groupBy: epic
+    epicField: customfield_10014
+    fields: "*all"
+`, srv.URL)), false)
+	require.NoError(t, err)
+
+	s := newEndpointScraper(cfg, &http.Client{})
+	result, err := s.scrape(context.Background(), cfg.Metrics[0])
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"A-100": 2, "none": 1}, result.Groups)
+
+	_, err = LoadConfiguration(writeConfig(t, `
+metrics:
+  - name: issues_per_epic
+    jql: project = A
+    fields: summary
+`), false)
+	require.Error(t, err)
+}
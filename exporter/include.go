@@ -0,0 +1,49 @@
+package exporter
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// includedConfiguration is the content of a file referenced by include.
+type includedConfiguration struct {
+	Metrics []MetricConfiguration `yaml:"metrics"`
+}
+
+// loadIncludes appends the metrics of all included files to the
+// configuration. Relative paths, including those of jqlFile settings in the
+// included files, are resolved against the directory of the including file.
+// Metric names must not be used in more than one file.
+func loadIncludes(cfg *Configuration, configPath string) error {
+	files := make(map[string]string, len(cfg.Metrics))
+	for _, m := range cfg.Metrics {
+		files[m.Name] = configPath
+	}
+	for _, include := range cfg.Include {
+		if !filepath.IsAbs(include) && configPath != "-" {
+			include = filepath.Join(filepath.Dir(configPath), include)
+		}
+		data, err := ioutil.ReadFile(include)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read included file %s", include)
+		}
+		var included includedConfiguration
+		if err := yaml.Unmarshal(data, &included); err != nil {
+			return errors.Wrapf(err, "failed to parse included file %s", include)
+		}
+		for _, m := range included.Metrics {
+			if file, ok := files[m.Name]; ok && file != include {
+				return errors.Errorf("metric %s is defined in both %s and %s", m.Name, file, include)
+			}
+			files[m.Name] = include
+			if m.JQLFile != "" && !filepath.IsAbs(m.JQLFile) {
+				m.JQLFile = filepath.Join(filepath.Dir(include), m.JQLFile)
+			}
+			cfg.Metrics = append(cfg.Metrics, m)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInclude(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0700))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0600))
+		return p
+	}
+	write("teams/backend.yml", `
+metrics:
+  - name: backend_bugs
+    jqlFile: backend.jql
+`)
+	write("teams/backend.jql", "project = BE AND type = Bug\n")
+	write("teams/frontend.yml", `
+metrics:
+  - name: frontend_bugs
+    jql: project = FE AND type = Bug
+`)
+	path := write("config.yml", `
+baseURL: https://jira.example.com
+include:
+  - teams/backend.yml
+  - teams/frontend.yml
+metrics:
+  - name: all_bugs
+    jql: type = Bug
+`)
+	cfg, err := LoadConfiguration(path, false)
+	require.NoError(t, err)
+	require.Len(t, cfg.Metrics, 3)
+	require.Equal(t, "all_bugs", cfg.Metrics[0].Name)
+	require.Equal(t, "backend_bugs", cfg.Metrics[1].Name)
+	require.Equal(t, "project = BE AND type = Bug", cfg.Metrics[1].JQL)
+	require.Equal(t, "frontend_bugs", cfg.Metrics[2].Name)
+
+	write("teams/duplicate.yml", `
+metrics:
+  - name: backend_bugs
+    jql: project = BE
+`)
+	path = write("config.yml", `
+include:
+  - teams/backend.yml
+  - teams/duplicate.yml
+`)
+	_, err = LoadConfiguration(path, false)
+	require.EqualError(t, err, "metric backend_bugs is defined in both "+filepath.Join(dir, "teams/backend.yml")+" and "+filepath.Join(dir, "teams/duplicate.yml"))
+}
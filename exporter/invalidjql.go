@@ -0,0 +1,59 @@
+package exporter
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// invalidJQLBackoff is the number of intervals a metric is not scraped after
+// Jira rejected its JQL. Fixing the JQL requires an operator anyway.
+const invalidJQLBackoff = 10
+
+// maxErrorMessageBytes limits how much of an error response is read.
+const maxErrorMessageBytes = 4096
+
+// jiraErrorResponse is the body Jira sends along with a 400 response.
+type jiraErrorResponse struct {
+	ErrorMessages []string          `json:"errorMessages"`
+	Errors        map[string]string `json:"errors"`
+}
+
+// readErrorMessage extracts the error messages from a Jira error response.
+// If the body is not a Jira error response, it is returned as is.
+func readErrorMessage(body io.Reader) string {
+	data, err := io.ReadAll(io.LimitReader(body, maxErrorMessageBytes))
+	if err != nil {
+		return ""
+	}
+	var resp jiraErrorResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return strings.TrimSpace(string(data))
+	}
+	messages := resp.ErrorMessages
+	for field, message := range resp.Errors {
+		messages = append(messages, field+": "+message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// isInvalidJQL returns true if Jira rejected the query of a scrape.
+func isInvalidJQL(err error) bool {
+	var scrapeErr *scrapeError
+	return errors.As(err, &scrapeErr) && scrapeErr.reason == errorReasonStatus && scrapeErr.statusCode == http.StatusBadRequest
+}
+
+// setInvalidJQL updates the invalid JQL gauge of the given metric.
+func setInvalidJQL(m MetricConfiguration, invalid bool) {
+	if m.InvalidJQL == nil {
+		return
+	}
+	if invalid {
+		m.InvalidJQL.Set(1)
+	} else {
+		m.InvalidJQL.Set(0)
+	}
+}
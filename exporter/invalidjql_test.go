@@ -0,0 +1,64 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadErrorMessage(t *testing.T) {
+	require.Equal(t, "Field 'foo' does not exist.", readErrorMessage(strings.NewReader(`{"errorMessages": ["Field 'foo' does not exist."], "errors": {}}`)))
+	require.Equal(t, "jql: invalid", readErrorMessage(strings.NewReader(`{"errors": {"jql": "invalid"}}`)))
+	require.Equal(t, "Bad Request", readErrorMessage(strings.NewReader("Bad Request\n")))
+}
+
+func TestInvalidJQL(t *testing.T) {
+	log, hook := logtest.NewNullLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	trigger := newScrapeTrigger()
+	cfg := &Configuration{
+		scrapeTrigger: trigger,
+		Metrics: []MetricConfiguration{
+			{
+				Name:           "test",
+				Help:           "test",
+				JQL:            "foo = bar",
+				ParsedInterval: time.Hour,
+			},
+		},
+	}
+	var requests int32
+	var invalidBeforeFix float64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"errorMessages": ["Field 'foo' does not exist or you do not have permission to view it."], "errors": {}}`)
+			// The backoff is skipped by triggered scrapes.
+			go trigger.fire()
+			return
+		}
+		invalidBeforeFix = testutil.ToFloat64(cfg.Metrics[0].InvalidJQL)
+		fmt.Fprint(w, `{"total": 1}`)
+		cancel()
+	}))
+	defer srv.Close()
+	cfg.BaseURL = srv.URL
+	require.NoError(t, SetupGauges(prometheus.NewRegistry(), prometheus.NewRegistry(), cfg.Metrics))
+	NewScraper(log, cfg, &http.Client{}).Run(ctx)
+
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	require.Equal(t, float64(1), invalidBeforeFix)
+	require.Equal(t, float64(0), testutil.ToFloat64(cfg.Metrics[0].InvalidJQL))
+	require.Contains(t, hook.AllEntries()[0].Data["error"].(error).Error(), "Field 'foo' does not exist")
+}
@@ -0,0 +1,66 @@
+package exporter
+
+import (
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// jiraDateLayout is the date format accepted inside JQL, e.g. 2024-01-15.
+const jiraDateLayout = "2006-01-02"
+
+// jqlFuncs returns the functions available inside JQL templates. now is
+// passed in so that all functions agree on the current time.
+func jqlFuncs(now time.Time) template.FuncMap {
+	return template.FuncMap{
+		"now": func() time.Time {
+			return now
+		},
+		"jiraDate": func(t time.Time) string {
+			return t.Format(jiraDateLayout)
+		},
+		"startOfMonth": func() time.Time {
+			return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		},
+	}
+}
+
+// renderJQL executes the JQL of the given metric as template. The data
+// consists of the configured variables and, for metrics expanded from a
+// matrix, the metric's variable values which take precedence.
+func renderJQL(m MetricConfiguration, variables map[string]string, now time.Time) (string, error) {
+	tmpl, err := template.New("jql").Option("missingkey=error").Funcs(jqlFuncs(now)).Parse(m.JQL)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid JQL template")
+	}
+	data := make(map[string]string, len(variables)+len(m.MatrixValues))
+	for k, v := range variables {
+		data[k] = v
+	}
+	for k, v := range m.MatrixValues {
+		data[k] = v
+	}
+	out := &strings.Builder{}
+	if err := tmpl.Execute(out, data); err != nil {
+		return "", errors.Wrap(err, "failed to render JQL template")
+	}
+	return out.String(), nil
+}
+
+// renderMetric returns a copy of the metric with its JQL rendered and its
+// window applied. All errors returned are of type *scrapeError.
+func renderMetric(m MetricConfiguration, variables map[string]string, now time.Time) (MetricConfiguration, error) {
+	// Metrics discovering projects are rendered once per project, see
+	// scrapeDiscovered.
+	if m.DiscoverProjects != nil && m.MatrixValues[projectLabel] == "" {
+		return m, nil
+	}
+	jql, err := renderJQL(m, variables, now)
+	if err != nil {
+		return m, &scrapeError{reason: errorReasonTemplate, err: err}
+	}
+	m.JQL = applyWindow(jql, m.Window)
+	return m, nil
+}
@@ -0,0 +1,32 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderJQL(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		jql      string
+		expected string
+	}{
+		{jql: "project = TEST", expected: "project = TEST"},
+		{jql: `created >= "{{ now.AddDate 0 0 -30 | jiraDate }}"`, expected: `created >= "2024-02-14"`},
+		{jql: `created >= "{{ startOfMonth | jiraDate }}"`, expected: `created >= "2024-03-01"`},
+		{jql: "project = {{ .project }} AND team = {{ .team }}", expected: "project = FOO AND team = a"},
+	} {
+		m := MetricConfiguration{JQL: tc.jql, MatrixValues: map[string]string{"project": "FOO"}}
+		jql, err := renderJQL(m, map[string]string{"project": "BAR", "team": "a"}, now)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, jql)
+	}
+
+	_, err := renderMetric(MetricConfiguration{JQL: "project = {{ .missing }}"}, nil, now)
+	require.Error(t, err)
+	require.Equal(t, lastErrorTemplate, classifyError(err))
+	_, err = renderJQL(MetricConfiguration{JQL: "project = {{ now"}, nil, now)
+	require.Error(t, err)
+}
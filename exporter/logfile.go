@@ -0,0 +1,113 @@
+package exporter
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// rotatingFile is a log file that is rotated once it exceeds maxSize bytes.
+// Rotated files are kept as path.1 (the newest) up to path.<maxBackups>.
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// openRotatingFile opens the log file at path for appending. A maxSize of 0
+// disables the rotation.
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	var err error
+	if f.file, f.size, err = openLogFile(path); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// openLogFile opens the file at path for appending and returns its size.
+func openLogFile(path string) (*os.File, int64, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return file, info.Size(), nil
+}
+
+// Write appends to the current file. If the rotation fails, the line is still
+// written to the current file and the error of the rotation is returned.
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var rotateErr error
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		rotateErr = f.rotate()
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// rotate moves the current file to path.1, shifting all older backups by
+// one, and opens a new file. If the new file can't be opened, writing
+// continues to the old one, which is rotated again once it has grown by
+// another maxSize bytes.
+func (f *rotatingFile) rotate() error {
+	if f.maxBackups == 0 {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	} else {
+		for i := f.maxBackups - 1; i > 0; i-- {
+			err := os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(f.path, f.path+".1"); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := f.swap(); err != nil {
+		f.size = 0
+		return err
+	}
+	return nil
+}
+
+// Reopen reopens the file, e.g. after it has been moved by an external tool
+// like logrotate. If that fails, writing continues to the old file.
+func (f *rotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.swap()
+}
+
+// swap opens the file at path and closes the current one once that
+// succeeded.
+func (f *rotatingFile) swap() error {
+	file, size, err := openLogFile(f.path)
+	if err != nil {
+		return err
+	}
+	old := f.file
+	f.file, f.size = file, size
+	return old.Close()
+}
+
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
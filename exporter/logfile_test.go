@@ -0,0 +1,61 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jiravars.log")
+	f, err := openRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+	defer f.Close()
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	read := func(path string) string {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(data)
+	}
+	require.Equal(t, "fourth\n", read(path))
+	require.Equal(t, "third\n", read(path+".1"))
+	require.Equal(t, "second\n", read(path+".2"))
+	_, err = os.Stat(path + ".3")
+	require.True(t, os.IsNotExist(err))
+
+	// After an external rotation, writes go to the new file.
+	require.NoError(t, os.Rename(path, path+".rotated"))
+	require.NoError(t, f.Reopen())
+	_, err = f.Write([]byte("fifth\n"))
+	require.NoError(t, err)
+	require.Equal(t, "fifth\n", read(path))
+	require.Equal(t, "fourth\n", read(path+".rotated"))
+}
+
+func TestRotatingFileFailure(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	require.NoError(t, os.Mkdir(dir, 0755))
+	path := filepath.Join(dir, "jiravars.log")
+	f, err := openRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.Write([]byte("first\n"))
+	require.NoError(t, err)
+
+	// Without the directory no new file can be opened, so writes keep going
+	// to the old one.
+	moved := filepath.Join(filepath.Dir(dir), "moved")
+	require.NoError(t, os.Rename(dir, moved))
+	require.Error(t, f.Reopen())
+	n, err := f.Write([]byte("second\n"))
+	require.Error(t, err)
+	require.Equal(t, 7, n)
+	data, err := os.ReadFile(filepath.Join(moved, "jiravars.log"))
+	require.NoError(t, err)
+	require.Equal(t, "first\nsecond\n", string(data))
+}
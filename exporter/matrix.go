@@ -0,0 +1,181 @@
+package exporter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// expandMatrix returns one metric per combination of the values of the
+// metric's variables. The label values are rendered as templates with the
+// combination as data and every variable is added as label. The JQL is
+// rendered on every scrape, see renderJQL. Metrics without variables are
+// returned unchanged.
+func expandMatrix(m MetricConfiguration) ([]MetricConfiguration, error) {
+	if len(m.Variables) == 0 {
+		return []MetricConfiguration{m}, nil
+	}
+	if m.Type == metricTypeSLA {
+		return nil, errors.New("variables are not supported for sla metrics")
+	}
+	names := make([]string, 0, len(m.Variables))
+	for name, values := range m.Variables {
+		if len(values) == 0 {
+			return nil, errors.Errorf("variable %s has no values", name)
+		}
+		seen := make(map[string]struct{}, len(values))
+		for _, v := range values {
+			if _, ok := seen[v]; ok {
+				return nil, errors.Errorf("duplicate value %q for variable %s", v, name)
+			}
+			seen[v] = struct{}{}
+		}
+		if _, ok := m.Labels[name]; ok {
+			return nil, errors.Errorf("variable %s conflicts with a label of the same name", name)
+		}
+		if name == gaugeLabel(m) {
+			return nil, errors.Errorf("variable %s conflicts with the %s label of the metric", name, name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	labels := make(map[string]*template.Template, len(m.Labels))
+	for k, v := range m.Labels {
+		var err error
+		labels[k], err = parseMatrixTemplate("label "+k, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var result []MetricConfiguration
+	for _, values := range matrixCombinations(names, m.Variables) {
+		expanded := m
+		expanded.MatrixValues = values
+		expanded.Labels = make(map[string]string, len(labels)+len(values))
+		for k, tmpl := range labels {
+			var err error
+			expanded.Labels[k], err = renderMatrixTemplate(tmpl, values)
+			if err != nil {
+				return nil, err
+			}
+		}
+		for k, v := range values {
+			expanded.Labels[k] = v
+		}
+		result = append(result, expanded)
+	}
+	return result, nil
+}
+
+func parseMatrixTemplate(name string, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid template in %s", name)
+	}
+	return tmpl, nil
+}
+
+func renderMatrixTemplate(tmpl *template.Template, values map[string]string) (string, error) {
+	out := &strings.Builder{}
+	if err := tmpl.Execute(out, values); err != nil {
+		return "", errors.Wrapf(err, "failed to render %s", tmpl.Name())
+	}
+	return out.String(), nil
+}
+
+// matrixCombinations returns all combinations of the values of the given
+// variables. The values of the first variable change slowest.
+func matrixCombinations(names []string, variables map[string][]string) []map[string]string {
+	combinations := []map[string]string{{}}
+	for _, name := range names {
+		next := make([]map[string]string, 0, len(combinations)*len(variables[name]))
+		for _, c := range combinations {
+			for _, v := range variables[name] {
+				combination := make(map[string]string, len(c)+1)
+				for k, existing := range c {
+					combination[k] = existing
+				}
+				combination[name] = v
+				next = append(next, combination)
+			}
+		}
+		combinations = next
+	}
+	return combinations
+}
+
+// metricID identifies a single worker. Metrics expanded from a matrix share
+// their name, so their variable values are appended.
+func metricID(m MetricConfiguration) string {
+	if len(m.MatrixValues) == 0 {
+		return m.Name
+	}
+	names := make([]string, 0, len(m.MatrixValues))
+	for name := range m.MatrixValues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", name, m.MatrixValues[name]))
+	}
+	return fmt.Sprintf("%s{%s}", m.Name, strings.Join(pairs, ","))
+}
+
+// setupMatrixGauges attaches the gauges of a metric expanded from a matrix.
+// All metrics of the same matrix share one GaugeVec with their labels as
+// variable labels, so the vectors are kept in vecs and only registered once.
+func setupMatrixGauges(registry prometheus.Registerer, vecs map[string]*prometheus.GaugeVec, m *MetricConfiguration, opts prometheus.GaugeOpts) error {
+	labelNames := make([]string, 0, len(m.Labels)+1)
+	for name := range m.Labels {
+		labelNames = append(labelNames, name)
+	}
+	sort.Strings(labelNames)
+	opts.ConstLabels = nil
+	shared := func(opts prometheus.GaugeOpts, labelNames []string) (*prometheus.GaugeVec, error) {
+		if vec, ok := vecs[opts.Name]; ok {
+			return vec, nil
+		}
+		vec := prometheus.NewGaugeVec(opts, labelNames)
+		if err := registry.Register(vec); err != nil {
+			return nil, err
+		}
+		vecs[opts.Name] = vec
+		return vec, nil
+	}
+	label := gaugeLabel(*m)
+	if label == "" {
+		vec, err := shared(opts, labelNames)
+		if err != nil {
+			return err
+		}
+		m.Gauge, err = vec.GetMetricWith(m.Labels)
+		return err
+	}
+	vec, err := shared(opts, append(labelNames, label))
+	if err != nil {
+		return err
+	}
+	m.GaugeVec, err = vec.CurryWith(m.Labels)
+	if err != nil {
+		return err
+	}
+	suffix := emptySuffix(*m)
+	if suffix == "" {
+		return nil
+	}
+	emptyOpts := opts
+	emptyOpts.Name = fmt.Sprintf("%s_%s", opts.Name, suffix)
+	emptyVec, err := shared(emptyOpts, labelNames)
+	if err != nil {
+		return err
+	}
+	m.Gauge, err = emptyVec.GetMetricWith(m.Labels)
+	return err
+}
@@ -0,0 +1,101 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandMatrix(t *testing.T) {
+	cfg, err := LoadConfiguration(writeConfig(t, `
+baseURL: https://jira.example.com
+metrics:
+  - name: open_issues
+    jql: project = {{ .project }} AND type = {{ .type }}
+    variables:
+      project: [FOO, BAR]
+      type: [Bug]
+    labels:
+      board: board-{{ .project }}
+  - name: plain
+    jql: project = TEST
+`), false)
+	require.NoError(t, err)
+	require.Len(t, cfg.Metrics, 3)
+	jql, err := renderJQL(cfg.Metrics[0], nil, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, "project = FOO AND type = Bug", jql)
+	require.Equal(t, map[string]string{"project": "FOO", "type": "Bug", "board": "board-FOO"}, cfg.Metrics[0].Labels)
+	require.Equal(t, "open_issues{project=BAR,type=Bug}", metricID(cfg.Metrics[1]))
+	require.Equal(t, "plain", metricID(cfg.Metrics[2]))
+
+	out := &strings.Builder{}
+	require.NoError(t, printURLs(out, cfg))
+	require.Contains(t, out.String(), "open_issues{project=FOO,type=Bug}: ")
+	require.Contains(t, out.String(), "jql=project+%3D+BAR+AND+type+%3D+Bug")
+
+	invalid := map[string]string{
+		"empty-values": `
+metrics:
+  - name: test
+    jql: project = {{ .project }}
+    variables:
+      project: []
+`,
+		"parse-error": `
+metrics:
+  - name: test
+    jql: project = {{ .project
+    variables:
+      project: [FOO]
+`,
+		"unknown-variable": `
+metrics:
+  - name: test
+    jql: project = {{ .team }}
+    variables:
+      project: [FOO]
+`,
+		"label-conflict": `
+metrics:
+  - name: test
+    jql: project = {{ .project }}
+    variables:
+      project: [FOO]
+    labels:
+      project: x
+`,
+	}
+	for name, content := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadConfiguration(writeConfig(t, content), false)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestSetupMatrixGauges(t *testing.T) {
+	m := MetricConfiguration{
+		Name:      "issues",
+		Help:      "test",
+		JQL:       "project = {{ .project }}",
+		GroupBy:   "labels",
+		Variables: map[string][]string{"project": {"FOO", "BAR"}},
+	}
+	metrics, err := expandMatrix(m)
+	require.NoError(t, err)
+	reg := prometheus.NewRegistry()
+	require.NoError(t, SetupGauges(reg, reg, metrics))
+
+	updateGauge(metrics[0], scrapeResult{Value: 1, Groups: map[string]float64{"backend": 2}}, nil)
+	updateGauge(metrics[1], scrapeResult{Value: 3, Groups: map[string]float64{"backend": 4}}, nil)
+	require.Equal(t, 2, testutil.CollectAndCount(reg, "jira_issues"))
+	require.Equal(t, float64(4), testutil.ToFloat64(metrics[1].GaugeVec.WithLabelValues("backend")))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics[0].Gauge))
+	require.Equal(t, float64(3), testutil.ToFloat64(metrics[1].Gauge))
+	require.Equal(t, 2, testutil.CollectAndCount(reg, "jira_up"))
+}
@@ -0,0 +1,55 @@
+package exporter
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Steps of a metric's normalize setting.
+const (
+	normalizeTrim               = "trim"
+	normalizeLowercase          = "lowercase"
+	normalizeCollapseWhitespace = "collapseWhitespace"
+)
+
+var normalizers = map[string]func(string) string{
+	normalizeTrim:      strings.TrimSpace,
+	normalizeLowercase: strings.ToLower,
+	normalizeCollapseWhitespace: func(v string) string {
+		return strings.Join(strings.Fields(v), " ")
+	},
+}
+
+// validateNormalize checks the normalize steps of a metric.
+func validateNormalize(m MetricConfiguration) error {
+	if len(m.Normalize) == 0 {
+		return nil
+	}
+	if m.GroupBy == "" {
+		return errors.New("normalize requires groupBy")
+	}
+	for _, step := range m.Normalize {
+		if _, ok := normalizers[step]; !ok {
+			return errors.Errorf("unsupported normalize step %q (supported: %s, %s, %s)", step, normalizeTrim, normalizeLowercase, normalizeCollapseWhitespace)
+		}
+	}
+	return nil
+}
+
+// normalizeValues applies the given steps in order to all values. Values
+// that become equal are merged so that an issue is counted only once per
+// group.
+func normalizeValues(steps []string, values []string) []string {
+	if len(steps) == 0 {
+		return values
+	}
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		for _, step := range steps {
+			v = normalizers[step](v)
+		}
+		result = append(result, v)
+	}
+	return uniqueStrings(result)
+}
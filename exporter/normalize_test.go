@@ -0,0 +1,37 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeValues(t *testing.T) {
+	g, err := lookupGrouper("components")
+	require.NoError(t, err)
+	issues := []issue{
+		{Fields: issueFields{Components: []namedValue{{Name: "Backend "}}}},
+		{Fields: issueFields{Components: []namedValue{{Name: "backend"}}}},
+		{Fields: issueFields{Components: []namedValue{{Name: "Backend "}, {Name: "backend"}}}},
+		{Fields: issueFields{Components: []namedValue{{Name: "Web  Frontend"}}}},
+	}
+	count := func(m MetricConfiguration) map[string]int {
+		counts := map[string]int{}
+		for _, i := range issues {
+			for _, v := range groupValues(g, i, m) {
+				counts[v]++
+			}
+		}
+		return counts
+	}
+	require.Equal(t, map[string]int{"Backend ": 2, "backend": 2, "Web  Frontend": 1}, count(MetricConfiguration{}))
+	m := MetricConfiguration{GroupBy: "components", Normalize: []string{normalizeTrim, normalizeLowercase, normalizeCollapseWhitespace}}
+	require.NoError(t, validateNormalize(m))
+	require.Equal(t, map[string]int{"backend": 3, "web frontend": 1}, count(m))
+
+	// Values that are empty after trimming count as having no value.
+	require.Equal(t, []string{"none"}, groupValues(g, issue{Fields: issueFields{Components: []namedValue{{Name: " "}}}}, m))
+
+	require.Error(t, validateNormalize(MetricConfiguration{GroupBy: "components", Normalize: []string{"upper"}}))
+	require.Error(t, validateNormalize(MetricConfiguration{Normalize: []string{normalizeTrim}}))
+}
@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	prometheusbridge "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+const defaultOTLPInterval = time.Minute
+
+type otlpConfiguration struct {
+	// Endpoint is the OTLP/HTTP endpoint metrics are pushed to, e.g.
+	// https://collector:4318. Pushing is disabled if empty.
+	Endpoint       string            `yaml:"endpoint"`
+	Interval       string            `yaml:"interval"`
+	Headers        map[string]string `yaml:"headers"`
+	TLS            tlsConfiguration  `yaml:"tls"`
+	ParsedInterval time.Duration     `yaml:"-"`
+}
+
+// countingExporter counts and logs failed exports of the wrapped exporter.
+type countingExporter struct {
+	sdkmetric.Exporter
+	log    *logrus.Logger
+	errors prometheus.Counter
+}
+
+func (e *countingExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	err := e.Exporter.Export(ctx, rm)
+	if err != nil {
+		e.errors.Inc()
+		e.log.WithError(err).Warn("Failed to export metrics via OTLP")
+	}
+	return err
+}
+
+// setupOTLPMetrics periodically pushes everything collected by the gatherer
+// to an OTLP endpoint. The /metrics endpoint keeps working alongside. The
+// returned function flushes and stops the exporter.
+func setupOTLPMetrics(ctx context.Context, log *logrus.Logger, cfg otlpConfiguration, gatherer prometheus.Gatherer, registry prometheus.Registerer) (func(context.Context) error, error) {
+	if cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	exportErrors := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "jira_otlp_export_errors_total",
+		Help: "Number of failed metric exports via OTLP",
+	})
+	if err := registry.Register(exportErrors); err != nil {
+		return nil, err
+	}
+	opts := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpointURL(cfg.Endpoint)}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlpmetrichttp.WithHeaders(cfg.Headers))
+	}
+	tlsConfig, err := cfg.TLS.build()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid OTLP TLS configuration")
+	}
+	if tlsConfig != nil {
+		opts = append(opts, otlpmetrichttp.WithTLSClientConfig(tlsConfig))
+	}
+	exporter, err := otlpmetrichttp.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	res, err := newResource(ctx)
+	if err != nil {
+		return nil, err
+	}
+	interval := cfg.ParsedInterval
+	if interval <= 0 {
+		interval = defaultOTLPInterval
+	}
+	reader := sdkmetric.NewPeriodicReader(
+		&countingExporter{Exporter: exporter, log: log, errors: exportErrors},
+		sdkmetric.WithInterval(interval),
+		sdkmetric.WithProducer(prometheusbridge.NewMetricProducer(prometheusbridge.WithGatherer(gatherer))),
+	)
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader), sdkmetric.WithResource(res))
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,74 @@
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupOTLPMetrics(t *testing.T) {
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+
+	t.Run("disabled", func(t *testing.T) {
+		reg := prometheus.NewRegistry()
+		shutdown, err := setupOTLPMetrics(context.Background(), log, otlpConfiguration{}, reg, reg)
+		require.NoError(t, err)
+		require.NoError(t, shutdown(context.Background()))
+		families, err := reg.Gather()
+		require.NoError(t, err)
+		require.Empty(t, families)
+	})
+
+	t.Run("push", func(t *testing.T) {
+		var mu sync.Mutex
+		var paths []string
+		var tokens []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			paths = append(paths, r.URL.Path)
+			tokens = append(tokens, r.Header.Get("Authorization"))
+		}))
+		defer srv.Close()
+		reg := prometheus.NewRegistry()
+		metrics := []MetricConfiguration{{Name: "test", Help: "test"}}
+		require.NoError(t, SetupGauges(reg, reg, metrics))
+		shutdown, err := setupOTLPMetrics(context.Background(), log, otlpConfiguration{
+			Endpoint: srv.URL + "/v1/metrics",
+			Headers:  map[string]string{"Authorization": "Bearer token"},
+		}, reg, reg)
+		require.NoError(t, err)
+		require.NoError(t, shutdown(context.Background()))
+		mu.Lock()
+		defer mu.Unlock()
+		require.Equal(t, []string{"/v1/metrics"}, paths)
+		require.Equal(t, []string{"Bearer token"}, tokens)
+	})
+
+	t.Run("export-errors", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer srv.Close()
+		reg := prometheus.NewRegistry()
+		shutdown, err := setupOTLPMetrics(context.Background(), log, otlpConfiguration{
+			Endpoint: srv.URL + "/v1/metrics",
+		}, reg, reg)
+		require.NoError(t, err)
+		require.Error(t, shutdown(context.Background()))
+		count, err := testutil.GatherAndCount(reg, "jira_otlp_export_errors_total")
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+		value, err := reg.Gather()
+		require.NoError(t, err)
+		require.Equal(t, float64(1), value[0].Metric[0].Counter.GetValue())
+	})
+}
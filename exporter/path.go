@@ -0,0 +1,116 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// defaultPathLabel is the label of metrics grouped by path that don't
+// configure one.
+const defaultPathLabel = "value"
+
+// pathFieldsPrefix is the prefix of all path expressions. Only the fields of
+// an issue can be looked at.
+const pathFieldsPrefix = "fields."
+
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// pathConfiguration selects the value issues are grouped by for groupBy
+// path using a dotted path into the issue, e.g.
+// fields.customfield_10010.value.
+type pathConfiguration struct {
+	Expression string `yaml:"expression"`
+	// Label is the name of the label holding the values.
+	Label string `yaml:"label"`
+}
+
+func validatePath(m *MetricConfiguration) error {
+	if m.Path == nil {
+		if m.GroupBy == "path" {
+			return errors.New("groupBy path requires path.expression")
+		}
+		return nil
+	}
+	if m.GroupBy != "path" {
+		return errors.New("path requires groupBy path")
+	}
+	if !strings.HasPrefix(m.Path.Expression, pathFieldsPrefix) {
+		return errors.Errorf("path.expression %q has to start with %q", m.Path.Expression, pathFieldsPrefix)
+	}
+	for _, segment := range pathSegments(m.Path.Expression) {
+		if segment == "" {
+			return errors.Errorf("path.expression %q contains an empty segment", m.Path.Expression)
+		}
+	}
+	if m.Path.Label == "" {
+		m.Path.Label = defaultPathLabel
+	}
+	if !labelNamePattern.MatchString(m.Path.Label) || strings.HasPrefix(m.Path.Label, "__") {
+		return errors.Errorf("path.label %q is not a valid label name", m.Path.Label)
+	}
+	return nil
+}
+
+// pathSegments returns the segments of the given expression below fields.
+// The first one is the field that has to be requested.
+func pathSegments(expression string) []string {
+	return strings.Split(strings.TrimPrefix(expression, pathFieldsPrefix), ".")
+}
+
+func pathValues(i issue, m MetricConfiguration) []string {
+	if m.Path == nil {
+		return nil
+	}
+	return uniqueStrings(evaluatePath(i.RawFields, pathSegments(m.Path.Expression)))
+}
+
+// evaluatePath returns the values found at the given path in a JSON
+// document. Arrays are looked into element by element, so a path can yield
+// several values. Strings, numbers and booleans are returned as is, while
+// missing values, nulls and objects yield nothing.
+func evaluatePath(data json.RawMessage, segments []string) []string {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+	switch data[0] {
+	case '[':
+		var elements []json.RawMessage
+		if err := json.Unmarshal(data, &elements); err != nil {
+			return nil
+		}
+		var values []string
+		for _, element := range elements {
+			values = append(values, evaluatePath(element, segments)...)
+		}
+		return values
+	case '{':
+		if len(segments) == 0 {
+			return nil
+		}
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(data, &object); err != nil {
+			return nil
+		}
+		return evaluatePath(object[segments[0]], segments[1:])
+	}
+	if len(segments) > 0 {
+		return nil
+	}
+	switch data[0] {
+	case '"':
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil
+		}
+		return []string{value}
+	case 'n':
+		return nil
+	}
+	// Numbers and booleans are kept as written by Jira.
+	return []string{string(data)}
+}
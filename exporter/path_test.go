@@ -0,0 +1,92 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluatePath(t *testing.T) {
+	fields := []byte(`{
+		"customfield_10010": {"value": {"name": "Platform", "id": 7, "active": true}},
+		"customfield_10020": [{"name": "Sprint 1"}, {"name": "Sprint 2"}, {"state": "closed"}],
+		"customfield_10030": null,
+		"labels": ["a", "b"]
+	}`)
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"customfield_10010.value.name", []string{"Platform"}},
+		{"customfield_10010.value.id", []string{"7"}},
+		{"customfield_10010.value.active", []string{"true"}},
+		{"customfield_10010.value", nil},
+		{"customfield_10010.value.name.first", nil},
+		{"customfield_10010.missing.name", nil},
+		{"customfield_10020.name", []string{"Sprint 1", "Sprint 2"}},
+		{"customfield_10030.value", nil},
+		{"labels", []string{"a", "b"}},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			require.Equal(t, test.expected, evaluatePath(fields, strings.Split(test.path, ".")))
+		})
+	}
+}
+
+func TestGroupByPath(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "customfield_10010", r.URL.Query().Get("fields"))
+		fmt.Fprint(w, `{"total": 3, "issues": [
+			{"key": "A-1", "fields": {"customfield_10010": {"value": {"name": "Platform"}}}},
+			{"key": "A-2", "fields": {"customfield_10010": {"value": {"name": "Platform"}}}},
+			{"key": "A-3", "fields": {"customfield_10010": null}}
+		]}`)
+	}))
+	defer srv.Close()
+	cfg, err := LoadConfiguration(writeConfig(t, fmt.Sprintf(`
+baseURL: %s
+metrics:
+  - name: issues_per_team
+    help: Issues per team
+    jql: project = A
+    groupBy: path
+    path:
+      expression: fields.customfield_10010.value.name
+      label: team
+`, srv.URL)), false)
+	require.NoError(t, err)
+	reg := prometheus.NewRegistry()
+	require.NoError(t, SetupGauges(reg, reg, cfg.Metrics))
+	m := cfg.Metrics[0]
+
+	s := newEndpointScraper(cfg, &http.Client{})
+	result, err := s.scrape(context.Background(), m)
+	require.NoError(t, err)
+	updateGauge(m, result, nil)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP jira_issues_per_team Issues per team
+# TYPE jira_issues_per_team gauge
+jira_issues_per_team{team="Platform"} 2
+jira_issues_per_team{team="none"} 1
+`), "jira_issues_per_team"))
+}
+
+func TestValidatePath(t *testing.T) {
+	m := MetricConfiguration{GroupBy: "path", Path: &pathConfiguration{Expression: "fields.customfield_10010.value"}}
+	require.NoError(t, validatePath(&m))
+	require.Equal(t, defaultPathLabel, m.Path.Label)
+
+	require.Error(t, validatePath(&MetricConfiguration{GroupBy: "path"}))
+	require.Error(t, validatePath(&MetricConfiguration{Path: &pathConfiguration{Expression: "fields.status"}}))
+	require.Error(t, validatePath(&MetricConfiguration{GroupBy: "path", Path: &pathConfiguration{Expression: "key"}}))
+	require.Error(t, validatePath(&MetricConfiguration{GroupBy: "path", Path: &pathConfiguration{Expression: "fields.status..name"}}))
+	require.Error(t, validatePath(&MetricConfiguration{GroupBy: "path", Path: &pathConfiguration{Expression: "fields.status", Label: "team-name"}}))
+}
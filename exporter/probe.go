@@ -0,0 +1,141 @@
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultProbeInterval is the interval of the probe if none has been
+// configured.
+const defaultProbeInterval = 30 * time.Second
+
+// probeConfiguration enables a periodic request to Jira's serverInfo
+// endpoint that measures the latency of Jira independently of the metrics.
+type probeConfiguration struct {
+	// Interval defaults to defaultProbeInterval.
+	Interval       string        `yaml:"interval"`
+	ParsedInterval time.Duration `yaml:"-"`
+	// Path defaults to serverInfo next to the search API.
+	Path string `yaml:"path"`
+}
+
+func validateProbe(cfg *Configuration) error {
+	p := cfg.Probe
+	if p == nil {
+		return nil
+	}
+	p.ParsedInterval = defaultProbeInterval
+	if p.Interval != "" {
+		var err error
+		if p.ParsedInterval, err = time.ParseDuration(p.Interval); err != nil {
+			return errors.Wrap(err, "invalid probe.interval")
+		}
+		if p.ParsedInterval <= 0 {
+			return errors.New("probe.interval must be positive")
+		}
+	}
+	if p.Path == "" {
+		p.Path = path.Join(path.Dir(cfg.APIPath), "serverInfo")
+	}
+	if p.Path[0] != '/' {
+		return errors.Errorf("probe.path %q must start with /", p.Path)
+	}
+	return nil
+}
+
+// prober requests the probe path of the first endpoint.
+type prober struct {
+	s        *endpointScraper
+	url      string
+	interval time.Duration
+	duration prometheus.Observer
+	failures prometheus.Gauge
+	// consecutive is the number of failed probes since the last success.
+	consecutive int
+}
+
+// setupProbe registers the metrics of the probe with telemetry. It returns
+// nil if the probe isn't enabled.
+func setupProbe(telemetry prometheus.Registerer, cfg *Configuration, client *http.Client) (*prober, error) {
+	if cfg.Probe == nil {
+		return nil, nil
+	}
+	duration := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "jira_probe_duration_seconds",
+		Help:    "Duration of the requests of the Jira probe",
+		Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	})
+	if err := telemetry.Register(duration); err != nil {
+		return nil, err
+	}
+	failures := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "jira_probe_consecutive_failures",
+		Help: "Number of failed Jira probes since the last successful one",
+	})
+	if err := telemetry.Register(failures); err != nil {
+		return nil, err
+	}
+	primary := endpointConfigs(cfg)[0]
+	return &prober{
+		s:        newEndpointScraper(primary, client),
+		url:      primary.BaseURL + cfg.Probe.Path,
+		interval: cfg.Probe.ParsedInterval,
+		duration: duration,
+		failures: failures,
+	}, nil
+}
+
+// run probes Jira right away and then every interval until ctx is done.
+func (p *prober) run(ctx context.Context, log *logrus.Logger) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.record(log, p.probe(ctx))
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// probe sends a single request. Its duration is recorded if Jira answered at
+// all.
+func (p *prober) probe(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, p.interval)
+	defer cancel()
+	start := time.Now()
+	resp, err := p.s.get(ctx, p.url)
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(resp.Body, p.s.maxResponseBytes)
+	p.duration.Observe(time.Since(start).Seconds())
+	if resp.StatusCode != http.StatusOK {
+		return &scrapeError{reason: errorReasonStatus, statusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// record updates the failure count and logs changes between failing and
+// succeeding probes.
+func (p *prober) record(log *logrus.Logger, err error) {
+	if err == nil {
+		if p.consecutive > 0 {
+			log.Infof("Jira probe succeeded again after %d failures", p.consecutive)
+		}
+		p.consecutive = 0
+	} else {
+		if p.consecutive == 0 {
+			log.WithError(err).Warn("Jira probe failed")
+		}
+		p.consecutive++
+	}
+	p.failures.Set(float64(p.consecutive))
+}
@@ -0,0 +1,82 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbe(t *testing.T) {
+	log, hook := logtest.NewNullLogger()
+	var failing atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/jira/rest/api/2/serverInfo", r.URL.Path)
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"version": "9.12.0"}`)
+	}))
+	defer srv.Close()
+	cfg, err := LoadConfiguration(writeConfig(t, fmt.Sprintf(`
+baseURL: %s
+apiPath: /jira/rest/api/2/search
+probe:
+  interval: 30s
+metrics: []
+`, srv.URL)), false)
+	require.NoError(t, err)
+	reg := prometheus.NewRegistry()
+	p, err := setupProbe(reg, cfg, &http.Client{})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	p.record(log, p.probe(ctx))
+	failing.Store(true)
+	p.record(log, p.probe(ctx))
+	p.record(log, p.probe(ctx))
+	require.Equal(t, float64(2), testutil.ToFloat64(p.failures))
+	require.Len(t, hook.AllEntries(), 1)
+
+	failing.Store(false)
+	p.record(log, p.probe(ctx))
+	require.Equal(t, float64(0), testutil.ToFloat64(p.failures))
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == "jira_probe_duration_seconds" {
+			// Failed probes that got a response are recorded as well.
+			require.Equal(t, uint64(4), family.GetMetric()[0].GetHistogram().GetSampleCount())
+		}
+	}
+	require.Contains(t, hook.LastEntry().Message, "after 2 failures")
+}
+
+func TestProbeDefaultInterval(t *testing.T) {
+	cfg, err := LoadConfiguration(writeConfig(t, `
+probe: {}
+metrics: []
+`), false)
+	require.NoError(t, err)
+	p, err := setupProbe(prometheus.NewRegistry(), cfg, &http.Client{})
+	require.NoError(t, err)
+	require.Equal(t, defaultProbeInterval, p.interval)
+}
+
+func TestProbeDisabled(t *testing.T) {
+	cfg, err := LoadConfiguration(writeConfig(t, `
+metrics: []
+`), false)
+	require.NoError(t, err)
+	p, err := setupProbe(prometheus.NewRegistry(), cfg, &http.Client{})
+	require.NoError(t, err)
+	require.Nil(t, p)
+}
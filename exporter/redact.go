@@ -0,0 +1,83 @@
+package exporter
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// redactedValue replaces credentials in log output.
+const redactedValue = "xxxxx"
+
+// minSecretLength is the length below which secrets are not redacted as
+// they would mangle unrelated parts of the log output.
+const minSecretLength = 4
+
+var (
+	urlUserinfo         = regexp.MustCompile(`\b([a-zA-Z][a-zA-Z0-9+.-]*://)[^/?#@\s]+@`)
+	sensitiveHeaderName = regexp.MustCompile(`(?i)authorization|cookie|token|secret|api-?key`)
+)
+
+// redactor removes credentials from strings: the userinfo of all URLs as
+// well as the passwords and sensitive header values of the configuration.
+type redactor struct {
+	mu      sync.RWMutex
+	secrets []string
+}
+
+// addSecrets registers the credentials of the given configuration and all
+// its endpoints.
+func (r *redactor) addSecrets(cfg *Configuration) {
+	secrets := []string{cfg.Password}
+	for _, e := range cfg.Endpoints {
+		secrets = append(secrets, e.Password)
+	}
+	for name, value := range cfg.HTTPHeaders {
+		if sensitiveHeaderName.MatchString(name) {
+			secrets = append(secrets, value)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLength {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+}
+
+func (r *redactor) redact(s string) string {
+	s = urlUserinfo.ReplaceAllString(s, "${1}"+redactedValue+"@")
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redactedValue)
+	}
+	return s
+}
+
+// redactingFormatter redacts the message and all fields of log entries,
+// including errors, before passing them on to the wrapped formatter.
+type redactingFormatter struct {
+	logrus.Formatter
+	redactor *redactor
+}
+
+func (f *redactingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	redacted := *entry
+	redacted.Message = f.redactor.redact(entry.Message)
+	redacted.Data = make(logrus.Fields, len(entry.Data))
+	for key, value := range entry.Data {
+		switch v := value.(type) {
+		case string:
+			redacted.Data[key] = f.redactor.redact(v)
+		case error:
+			redacted.Data[key] = f.redactor.redact(v.Error())
+		default:
+			redacted.Data[key] = value
+		}
+	}
+	return f.Formatter.Format(&redacted)
+}
@@ -0,0 +1,153 @@
+package exporter
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	metricTypeCount          = "count"
+	metricTypeResolutionTime = "resolutionTime"
+	metricTypeTimeSpent      = "timeSpent"
+	metricTypeSLA            = "sla"
+)
+
+// defaultAggregates are computed for resolutionTime metrics that don't
+// specify any.
+var defaultAggregates = []string{"avg", "max", "p90"}
+
+// jiraTimeLayout is the format Jira uses for timestamps like created or
+// resolutiondate, e.g. 2024-01-15T10:30:00.000+0100.
+const jiraTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+func parseJiraTime(value string) (time.Time, error) {
+	t, err := time.Parse(jiraTimeLayout, value)
+	if err != nil {
+		// Some Jira versions use RFC 3339 offsets instead.
+		if t2, err2 := time.Parse(time.RFC3339, value); err2 == nil {
+			return t2, nil
+		}
+	}
+	return t, err
+}
+
+// validateMetricType checks the type specific settings of a metric and
+// fills in defaults.
+func validateMetricType(m *MetricConfiguration) error {
+	switch m.Type {
+	case "", metricTypeCount, metricTypeTimeSpent:
+		if len(m.Aggregates) > 0 {
+			return errors.Errorf("aggregates are not supported for %s metrics", m.Type)
+		}
+		return nil
+	case metricTypeResolutionTime:
+		if m.GroupBy != "" {
+			return errors.New("groupBy is not supported for resolutionTime metrics")
+		}
+		if len(m.Aggregates) == 0 {
+			m.Aggregates = defaultAggregates
+		}
+		for _, a := range m.Aggregates {
+			if _, err := aggregateFunc(a); err != nil {
+				return err
+			}
+		}
+		return nil
+	case metricTypeSLA:
+		return validateSLAMetric(m)
+	case metricTypeBoardColumns:
+		return validateBoardMetric(m)
+	case metricTypeEpicProgress:
+		return validateEpicMetric(m)
+	case metricTypeSprint:
+		return validateSprintMetric(m)
+	}
+	return errors.Errorf("unsupported type %q", m.Type)
+}
+
+// aggregateFunc returns the function computing the given aggregate over a
+// sorted list of values. Supported are avg, min, max and quantiles in the
+// form pNN (e.g. p50, p90, p99).
+func aggregateFunc(name string) (func(sorted []float64) float64, error) {
+	switch name {
+	case "avg":
+		return func(sorted []float64) float64 {
+			sum := 0.0
+			for _, v := range sorted {
+				sum += v
+			}
+			return sum / float64(len(sorted))
+		}, nil
+	case "min":
+		return func(sorted []float64) float64 { return sorted[0] }, nil
+	case "max":
+		return func(sorted []float64) float64 { return sorted[len(sorted)-1] }, nil
+	}
+	if strings.HasPrefix(name, "p") {
+		q, err := strconv.ParseFloat(name[1:], 64)
+		if err == nil && q > 0 && q <= 100 {
+			return func(sorted []float64) float64 {
+				// Nearest-rank method.
+				rank := int(math.Ceil(q / 100 * float64(len(sorted))))
+				return sorted[rank-1]
+			}, nil
+		}
+	}
+	return nil, errors.Errorf("unsupported aggregate %q", name)
+}
+
+// aggregate computes all requested aggregates over the given values. No
+// aggregates are returned for an empty list.
+func aggregate(values []float64, aggregates []string) map[string]float64 {
+	result := make(map[string]float64, len(aggregates))
+	if len(values) == 0 {
+		return result
+	}
+	sort.Float64s(values)
+	for _, name := range aggregates {
+		fn, err := aggregateFunc(name)
+		if err != nil {
+			continue
+		}
+		result[name] = fn(values)
+	}
+	return result
+}
+
+// resolutionTime returns the time between the creation and the resolution
+// of an issue. ok is false for unresolved issues or issues with missing or
+// invalid timestamps.
+func resolutionTime(i issue) (time.Duration, bool) {
+	if i.Fields.Created == "" || i.Fields.ResolutionDate == "" {
+		return 0, false
+	}
+	created, err := parseJiraTime(i.Fields.Created)
+	if err != nil {
+		return 0, false
+	}
+	resolved, err := parseJiraTime(i.Fields.ResolutionDate)
+	if err != nil {
+		return 0, false
+	}
+	return resolved.Sub(created), true
+}
+
+// scrapeResolutionTime computes the configured aggregates over the
+// resolution time (in seconds) of all resolved issues.
+func (s *endpointScraper) scrapeResolutionTime(ctx context.Context, m MetricConfiguration) (scrapeResult, error) {
+	var durations []float64
+	result, err := s.fetchIssues(ctx, m, func(i issue) error {
+		if d, ok := resolutionTime(i); ok {
+			durations = append(durations, d.Seconds())
+		}
+		return nil
+	})
+	result.Groups = aggregate(durations, m.Aggregates)
+	return result, err
+}
@@ -0,0 +1,69 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJiraTime(t *testing.T) {
+	ts, err := parseJiraTime("2024-01-15T10:30:00.000+0100")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC), ts.UTC())
+	_, err = parseJiraTime("yesterday")
+	require.Error(t, err)
+}
+
+func TestAggregate(t *testing.T) {
+	values := []float64{10, 1, 4, 3, 2, 9, 8, 7, 6, 5}
+	require.Equal(t, map[string]float64{
+		"avg": 5.5,
+		"min": 1,
+		"max": 10,
+		"p50": 5,
+		"p90": 9,
+	}, aggregate(values, []string{"avg", "min", "max", "p50", "p90"}))
+	require.Empty(t, aggregate(nil, defaultAggregates))
+	_, err := aggregateFunc("p0")
+	require.Error(t, err)
+	_, err = aggregateFunc("median")
+	require.Error(t, err)
+}
+
+func TestValidateMetricType(t *testing.T) {
+	m := MetricConfiguration{Type: metricTypeResolutionTime}
+	require.NoError(t, validateMetricType(&m))
+	require.Equal(t, defaultAggregates, m.Aggregates)
+	require.Error(t, validateMetricType(&MetricConfiguration{Type: "unknown"}))
+	require.Error(t, validateMetricType(&MetricConfiguration{Aggregates: []string{"avg"}}))
+	require.Error(t, validateMetricType(&MetricConfiguration{Type: metricTypeResolutionTime, Aggregates: []string{"median"}}))
+}
+
+func TestScrapeResolutionTime(t *testing.T) {
+	var fields string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fields = r.URL.Query().Get("fields")
+		fmt.Fprint(w, `{"total": 4, "issues": [
+			{"fields": {"created": "2024-01-01T00:00:00.000+0000", "resolutiondate": "2024-01-01T01:00:00.000+0000"}},
+			{"fields": {"created": "2024-01-01T00:00:00.000+0100", "resolutiondate": "2024-01-01T02:00:00.000+0000"}},
+			{"fields": {"created": "2024-01-01T00:00:00.000+0000", "resolutiondate": null}},
+			{"fields": {"created": "2024-01-01T00:00:00.000+0000"}}
+		]}`)
+	}))
+	defer srv.Close()
+	s := newEndpointScraper(&Configuration{BaseURL: srv.URL}, &http.Client{})
+	result, err := s.scrape(context.Background(), MetricConfiguration{
+		Name:       "test",
+		JQL:        "resolved >= -30d",
+		Type:       metricTypeResolutionTime,
+		Aggregates: []string{"avg", "max"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "created,resolutiondate", fields)
+	require.Equal(t, map[string]float64{"avg": 7200, "max": 10800}, result.Groups)
+}
@@ -0,0 +1,30 @@
+package exporter
+
+import (
+	"mime"
+	"strings"
+)
+
+// invalidResponseError reports a response that is not a Jira search result
+// at all, e.g. an HTML error page of a proxy sent with status 200.
+type invalidResponseError struct {
+	msg string
+}
+
+func (e *invalidResponseError) Error() string {
+	return e.msg
+}
+
+// isJSONContentType reports whether a response with the given Content-Type
+// header may contain JSON. Responses without a Content-Type and text/plain,
+// which some proxies use for everything, are accepted as well.
+func isJSONContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || mediaType == "text/plain" || strings.HasSuffix(mediaType, "+json")
+}
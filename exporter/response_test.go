@@ -0,0 +1,64 @@
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// serveFixture answers every request with the given file from testdata.
+func serveFixture(t *testing.T, name string, contentType string) *httptest.Server {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestInvalidResponses(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		fixture     string
+		contentType string
+	}{
+		{name: "html", fixture: "proxy-error.html", contentType: "text/html; charset=utf-8"},
+		{name: "html-as-text", fixture: "proxy-error.html", contentType: "text/plain"},
+		{name: "unexpected-shape", fixture: "unexpected-shape.json", contentType: "application/json"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := serveFixture(t, tc.fixture, tc.contentType)
+			s := newEndpointScraper(&Configuration{BaseURL: srv.URL}, &http.Client{})
+			_, err := s.scrape(context.Background(), MetricConfiguration{Name: "test", JQL: "project = TEST", GroupBy: "priority"})
+			var scrapeErr *scrapeError
+			require.True(t, errors.As(err, &scrapeErr))
+			require.Equal(t, errorReasonInvalidResponse, scrapeErr.reason)
+			require.Equal(t, lastErrorInvalid, classifyError(err))
+		})
+	}
+}
+
+func TestPartialResponse(t *testing.T) {
+	srv := serveFixture(t, "partial.json", "application/json;charset=UTF-8")
+	s := newEndpointScraper(&Configuration{BaseURL: srv.URL}, &http.Client{})
+	result, err := s.scrape(context.Background(), MetricConfiguration{Name: "test", JQL: "project = TEST", GroupBy: "priority"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"Major": 1, "none": 3}, result.Groups)
+	require.Equal(t, 2, result.MissingFields)
+}
+
+func TestIsJSONContentType(t *testing.T) {
+	require.True(t, isJSONContentType(""))
+	require.True(t, isJSONContentType("application/json;charset=UTF-8"))
+	require.True(t, isJSONContentType("application/vnd.api+json"))
+	require.False(t, isJSONContentType("text/html"))
+	require.False(t, isJSONContentType("invalid;;"))
+}
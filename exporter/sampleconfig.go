@@ -0,0 +1,40 @@
+package exporter
+
+// sampleConfig is printed by --sample-config. It is loaded in the tests so
+// that it stays in sync with the configuration structs.
+const sampleConfig = `# Base URL of the JIRA instance.
+baseURL: https://jira.company.net
+
+# Credentials used for basic authentication. The password can also be passed
+# via the JIRA_PASSWORD environment variable.
+login: me
+password: secret
+
+# Set the mode to "none" for anonymous access.
+auth:
+  mode: basic
+
+# Headers sent with every request to JIRA.
+httpHeaders:
+  X-Custom-Header: custom-value
+
+# Values applied to every metric that doesn't set them itself.
+defaults:
+  interval: 5m
+  labels:
+    team: taa
+
+metrics:
+  # Exports the number of matching issues as jira_taa_backlog_size.
+  - name: taa_backlog_size
+    help: Number of items inside our backlog
+    jql: project = TAA AND status = Open
+
+  # Exports the number of matching issues per status category as
+  # jira_taa_issues_by_category{status_category="..."}.
+  - name: taa_issues_by_category
+    help: Issues per status category
+    interval: 10m
+    jql: project = TAA
+    groupBy: statusCategory
+`
@@ -0,0 +1,81 @@
+package exporter
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Supported values for the labelSanitization setting.
+const (
+	// sanitizePassthrough leaves names and values untouched.
+	sanitizePassthrough = "passthrough"
+	// sanitizeLowercaseDash lowercases values and replaces every run of
+	// characters other than letters and digits with a single dash.
+	sanitizeLowercaseDash = "lowercase-dash"
+	// sanitizeStrict lowercases values and replaces every run of characters
+	// other than ASCII letters, digits and underscores with a single
+	// underscore.
+	sanitizeStrict = "strict"
+)
+
+// sanitizedEmptyValue replaces values that consist of separators only.
+const sanitizedEmptyValue = "unknown"
+
+var (
+	nonAlphanumericRun = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+	nonWordRun         = regexp.MustCompile(`[^a-z0-9_]+`)
+	invalidMetricChars = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+)
+
+func validateSanitizationMode(mode string) error {
+	switch mode {
+	case sanitizePassthrough, sanitizeLowercaseDash, sanitizeStrict:
+		return nil
+	}
+	return errors.Errorf("unsupported label sanitization mode %q", mode)
+}
+
+// sanitizeLabelValue normalizes a label value according to the given mode.
+// The mapping is stable: the same input always results in the same output.
+// lowercase-dash keeps letters and digits of all scripts, strict only ASCII
+// ones.
+func sanitizeLabelValue(mode string, value string) string {
+	var sanitized string
+	switch mode {
+	case sanitizeLowercaseDash:
+		sanitized = strings.Trim(nonAlphanumericRun.ReplaceAllString(strings.ToLower(value), "-"), "-")
+	case sanitizeStrict:
+		sanitized = strings.Trim(nonWordRun.ReplaceAllString(strings.ToLower(value), "_"), "_")
+	default:
+		return value
+	}
+	if sanitized == "" && value != "" {
+		return sanitizedEmptyValue
+	}
+	return sanitized
+}
+
+// sanitizeLabelValues sanitizes the given values and drops the duplicates
+// this may result in.
+func sanitizeLabelValues(mode string, values []string) []string {
+	if mode == sanitizePassthrough || mode == "" {
+		return values
+	}
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		result = append(result, sanitizeLabelValue(mode, v))
+	}
+	return uniqueStrings(result)
+}
+
+// sanitizeMetricName replaces all characters that are not allowed inside a
+// Prometheus metric name with underscores. In passthrough mode the name is
+// left untouched.
+func sanitizeMetricName(mode string, name string) string {
+	if mode == sanitizePassthrough || mode == "" {
+		return name
+	}
+	return invalidMetricChars.ReplaceAllString(name, "_")
+}
@@ -0,0 +1,34 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeLabelValue(t *testing.T) {
+	value := " Team A/B (Backend) & Ops_2 "
+	require.Equal(t, value, sanitizeLabelValue(sanitizePassthrough, value))
+	require.Equal(t, "team-a-b-backend-ops-2", sanitizeLabelValue(sanitizeLowercaseDash, value))
+	require.Equal(t, "team_a_b_backend_ops_2", sanitizeLabelValue(sanitizeStrict, value))
+	require.Equal(t, "überteam", sanitizeLabelValue(sanitizePassthrough, "überteam"))
+	require.Equal(t, "überteam", sanitizeLabelValue(sanitizeLowercaseDash, "Überteam"))
+	require.Equal(t, "berteam_2", sanitizeLabelValue(sanitizeStrict, "Überteam 2"))
+	require.Equal(t, "日本", sanitizeLabelValue(sanitizeLowercaseDash, "日本"))
+	require.Equal(t, sanitizedEmptyValue, sanitizeLabelValue(sanitizeStrict, "日本"))
+	require.Equal(t, sanitizedEmptyValue, sanitizeLabelValue(sanitizeLowercaseDash, "/// "))
+	require.Equal(t, "", sanitizeLabelValue(sanitizeStrict, ""))
+}
+
+func TestSanitizedGroupValues(t *testing.T) {
+	g, err := lookupGrouper("components")
+	require.NoError(t, err)
+	m := MetricConfiguration{GroupBy: "components", LabelSanitization: sanitizeLowercaseDash}
+	i := issue{Fields: issueFields{Components: []namedValue{{Name: "Front End"}, {Name: "front-end"}, {Name: "Backend"}}}}
+	require.Equal(t, []string{"front-end", "backend"}, groupValues(g, i, m))
+}
+
+func TestSanitizeMetricName(t *testing.T) {
+	require.Equal(t, "open-bugs", sanitizeMetricName(sanitizePassthrough, "open-bugs"))
+	require.Equal(t, "open_bugs_team_a", sanitizeMetricName(sanitizeStrict, "open-bugs team/a"))
+}
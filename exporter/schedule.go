@@ -0,0 +1,124 @@
+package exporter
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultBusinessDays are the days business hours apply to if no weekdays
+// have been configured.
+var defaultBusinessDays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+// scheduleConfiguration scrapes a metric at a different interval during
+// business hours. The metric's own interval applies outside of them.
+type scheduleConfiguration struct {
+	// BusinessHours is the daily window in the form 09:00-17:00. Windows
+	// ending before they start span midnight.
+	BusinessHours string `yaml:"businessHours"`
+	// Timezone is the IANA name of the business hours' time zone, UTC if
+	// empty.
+	Timezone string `yaml:"timezone"`
+	// Weekdays are the days with business hours, Monday to Friday if empty.
+	Weekdays []string `yaml:"weekdays"`
+	// Interval is the interval during business hours.
+	Interval string `yaml:"interval"`
+
+	location       *time.Location
+	start, end     time.Duration
+	days           map[time.Weekday]bool
+	parsedInterval time.Duration
+}
+
+// validateSchedule parses the schedule of the given metric. Its interval is
+// subject to the same minimum as the metric's interval.
+func validateSchedule(m *MetricConfiguration, minInterval time.Duration, allowFastIntervals bool) error {
+	s := m.Schedule
+	if s == nil {
+		return nil
+	}
+	var err error
+	if s.location, err = time.LoadLocation(s.Timezone); err != nil {
+		return errors.Wrap(err, "invalid schedule.timezone")
+	}
+	parts := strings.Split(s.BusinessHours, "-")
+	if len(parts) != 2 {
+		return errors.Errorf("schedule.businessHours %q must have the form 09:00-17:00", s.BusinessHours)
+	}
+	if s.start, err = parseTimeOfDay(parts[0]); err != nil {
+		return errors.Wrap(err, "invalid schedule.businessHours")
+	}
+	if s.end, err = parseTimeOfDay(parts[1]); err != nil {
+		return errors.Wrap(err, "invalid schedule.businessHours")
+	}
+	if s.start == s.end {
+		return errors.New("schedule.businessHours must not be empty")
+	}
+	s.days = make(map[time.Weekday]bool, 7)
+	if len(s.Weekdays) == 0 {
+		for _, d := range defaultBusinessDays {
+			s.days[d] = true
+		}
+	}
+	for _, name := range s.Weekdays {
+		d, err := parseWeekday(name)
+		if err != nil {
+			return err
+		}
+		s.days[d] = true
+	}
+	if s.Interval == "" {
+		return errors.New("schedule.interval is required")
+	}
+	if s.parsedInterval, err = time.ParseDuration(s.Interval); err != nil {
+		return errors.Wrap(err, "invalid schedule.interval")
+	}
+	if s.parsedInterval <= 0 {
+		return errors.New("schedule.interval must be positive")
+	}
+	if s.parsedInterval < minInterval && !allowFastIntervals {
+		return errors.Errorf("schedule.interval %s is below the minimum of %s", s.parsedInterval, minInterval)
+	}
+	return nil
+}
+
+// parseTimeOfDay parses a time like 09:30 into the duration since midnight.
+func parseTimeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, errors.Errorf("%q is not a time like 09:00", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func parseWeekday(name string) (time.Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(name, d.String()) || strings.EqualFold(name, d.String()[:3]) {
+			return d, nil
+		}
+	}
+	return 0, errors.Errorf("invalid weekday %q in schedule.weekdays", name)
+}
+
+// inBusinessHours reports whether the given time is within business hours.
+// The weekday of windows spanning midnight is the one they start on.
+func (s *scheduleConfiguration) inBusinessHours(now time.Time) bool {
+	local := now.In(s.location)
+	sinceMidnight := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute + time.Duration(local.Second())*time.Second
+	if s.start < s.end {
+		return s.days[local.Weekday()] && sinceMidnight >= s.start && sinceMidnight < s.end
+	}
+	if sinceMidnight >= s.start {
+		return s.days[local.Weekday()]
+	}
+	return sinceMidnight < s.end && s.days[local.AddDate(0, 0, -1).Weekday()]
+}
+
+// intervalAt returns the interval of the given metric that applies at now.
+func intervalAt(m MetricConfiguration, now time.Time) time.Duration {
+	if m.Schedule != nil && m.Schedule.inBusinessHours(now) {
+		return m.Schedule.parsedInterval
+	}
+	return m.ParsedInterval
+}
@@ -0,0 +1,72 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule(t *testing.T) {
+	cfg, err := LoadConfiguration(writeConfig(t, `
+metrics:
+  - name: open_issues
+    jql: project = A
+    interval: 15m
+    schedule:
+      businessHours: 09:00-17:00
+      timezone: Europe/Vienna
+      interval: 1m
+`), false)
+	require.NoError(t, err)
+	m := cfg.Metrics[0]
+	vienna, err := time.LoadLocation("Europe/Vienna")
+	require.NoError(t, err)
+
+	// Tuesday morning, the interval switches once business hours start.
+	now := time.Date(2024, 3, 5, 8, 50, 0, 0, vienna)
+	now = nextScrape(m, now)
+	require.Equal(t, time.Date(2024, 3, 5, 9, 5, 0, 0, vienna), now)
+	now = nextScrape(m, now)
+	require.Equal(t, time.Date(2024, 3, 5, 9, 6, 0, 0, vienna), now)
+	// In the evening it switches back.
+	require.Equal(t, time.Date(2024, 3, 5, 17, 0, 0, 0, vienna), nextScrape(m, time.Date(2024, 3, 5, 16, 59, 0, 0, vienna)))
+	require.Equal(t, time.Date(2024, 3, 5, 17, 15, 0, 0, vienna), nextScrape(m, time.Date(2024, 3, 5, 17, 0, 0, 0, vienna)))
+	// The time zone is honored.
+	require.Equal(t, time.Minute, intervalAt(m, time.Date(2024, 3, 5, 8, 30, 0, 0, time.UTC)))
+	// Weekends are off hours.
+	require.Equal(t, 15*time.Minute, intervalAt(m, time.Date(2024, 3, 9, 10, 0, 0, 0, vienna)))
+
+	m.Align = true
+	require.Equal(t, time.Date(2024, 3, 5, 9, 0, 0, 0, vienna), nextScrape(m, time.Date(2024, 3, 5, 8, 50, 0, 0, vienna)))
+	require.Equal(t, time.Date(2024, 3, 5, 9, 1, 0, 0, vienna), nextScrape(m, time.Date(2024, 3, 5, 9, 0, 0, 0, vienna)))
+}
+
+func TestScheduleAcrossMidnight(t *testing.T) {
+	m := MetricConfiguration{ParsedInterval: time.Hour, Schedule: &scheduleConfiguration{
+		BusinessHours: "22:00-06:00",
+		Weekdays:      []string{"fri"},
+		Interval:      "5m",
+	}}
+	require.NoError(t, validateSchedule(&m, time.Minute, false))
+	require.Equal(t, 5*time.Minute, intervalAt(m, time.Date(2024, 3, 8, 23, 0, 0, 0, time.UTC)))
+	require.Equal(t, 5*time.Minute, intervalAt(m, time.Date(2024, 3, 9, 5, 0, 0, 0, time.UTC)))
+	require.Equal(t, time.Hour, intervalAt(m, time.Date(2024, 3, 9, 23, 0, 0, 0, time.UTC)))
+	require.Equal(t, time.Hour, intervalAt(m, time.Date(2024, 3, 8, 5, 0, 0, 0, time.UTC)))
+}
+
+func TestValidateSchedule(t *testing.T) {
+	for name, schedule := range map[string]scheduleConfiguration{
+		"timezone":      {BusinessHours: "09:00-17:00", Timezone: "Mars/Olympus", Interval: "1m"},
+		"hours":         {BusinessHours: "9-17", Interval: "1m"},
+		"empty-window":  {BusinessHours: "09:00-09:00", Interval: "1m"},
+		"weekday":       {BusinessHours: "09:00-17:00", Weekdays: []string{"funday"}, Interval: "1m"},
+		"no-interval":   {BusinessHours: "09:00-17:00"},
+		"fast-interval": {BusinessHours: "09:00-17:00", Interval: "10s"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s := schedule
+			require.Error(t, validateSchedule(&MetricConfiguration{Schedule: &s}, 30*time.Second, false))
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var defaultAtRiskThresholds = []string{"30m"}
+
+// slaValue is the part of a Jira Service Management SLA field we are
+// interested in.
+type slaValue struct {
+	OngoingCycle *struct {
+		Breached      bool `json:"breached"`
+		RemainingTime struct {
+			Millis int64 `json:"millis"`
+		} `json:"remainingTime"`
+	} `json:"ongoingCycle"`
+}
+
+func validateSLAMetric(m *MetricConfiguration) error {
+	if !strings.HasPrefix(m.SLAField, customFieldPrefix) {
+		return errors.Errorf("slaField must be a custom field ID like customfield_10030, got %q", m.SLAField)
+	}
+	if m.GroupBy != "" || len(m.Aggregates) > 0 {
+		return errors.New("groupBy and aggregates are not supported for sla metrics")
+	}
+	if len(m.AtRiskThresholds) == 0 {
+		m.AtRiskThresholds = defaultAtRiskThresholds
+	}
+	m.ParsedAtRiskThresholds = make([]time.Duration, 0, len(m.AtRiskThresholds))
+	for _, threshold := range m.AtRiskThresholds {
+		d, err := time.ParseDuration(threshold)
+		if err != nil {
+			return errors.Wrapf(err, "invalid atRiskThreshold %q", threshold)
+		}
+		m.ParsedAtRiskThresholds = append(m.ParsedAtRiskThresholds, d)
+	}
+	return nil
+}
+
+// setupSLAGauges registers jira_<name>_breached_requests and
+// jira_<name>_at_risk_requests for the given sla metric.
+func setupSLAGauges(registry prometheus.Registerer, m *MetricConfiguration, opts prometheus.GaugeOpts) error {
+	breachedOpts := opts
+	breachedOpts.Name = opts.Name + "_breached_requests"
+	m.Gauge = prometheus.NewGauge(breachedOpts)
+	if err := registry.Register(m.Gauge); err != nil {
+		return err
+	}
+	atRiskOpts := opts
+	atRiskOpts.Name = opts.Name + "_at_risk_requests"
+	m.GaugeVec = prometheus.NewGaugeVec(atRiskOpts, []string{"threshold"})
+	return registry.Register(m.GaugeVec)
+}
+
+func sendSLAStatsD(client *statsdClient, m MetricConfiguration, result scrapeResult) {
+	client.gauge(m.Name+"_breached_requests", result.Value, m.Labels)
+	for threshold, count := range result.Groups {
+		tags := make(map[string]string, len(m.Labels)+1)
+		for k, v := range m.Labels {
+			tags[k] = v
+		}
+		tags["threshold"] = threshold
+		client.gauge(m.Name+"_at_risk_requests", count, tags)
+	}
+}
+
+// scrapeSLA counts the requests whose ongoing SLA cycle has been breached
+// (Value) or will breach within each of the at-risk thresholds (Groups).
+// Requests without an ongoing cycle or without an SLA (null) are ignored,
+// but the SLA field missing entirely indicates a misconfiguration and fails
+// the scrape.
+func (s *endpointScraper) scrapeSLA(ctx context.Context, m MetricConfiguration) (scrapeResult, error) {
+	breached := 0.0
+	atRisk := make(map[string]float64, len(m.AtRiskThresholds))
+	for _, threshold := range m.AtRiskThresholds {
+		atRisk[threshold] = 0
+	}
+	result, err := s.fetchIssues(ctx, m, func(i issue) error {
+		raw, ok := i.Fields.Custom[m.SLAField]
+		if !ok {
+			return fmt.Errorf("SLA field %s is not present on issue %s", m.SLAField, i.Key)
+		}
+		if bytes.Equal(raw, []byte("null")) {
+			return nil
+		}
+		var value slaValue
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return errors.Wrapf(err, "invalid SLA field %s on issue %s", m.SLAField, i.Key)
+		}
+		if value.OngoingCycle == nil {
+			return nil
+		}
+		if value.OngoingCycle.Breached {
+			breached++
+			return nil
+		}
+		remaining := time.Duration(value.OngoingCycle.RemainingTime.Millis) * time.Millisecond
+		for idx, threshold := range m.ParsedAtRiskThresholds {
+			if remaining <= threshold {
+				atRisk[m.AtRiskThresholds[idx]]++
+			}
+		}
+		return nil
+	})
+	result.Value = breached
+	result.Groups = atRisk
+	return result, err
+}
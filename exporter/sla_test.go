@@ -0,0 +1,69 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrapeSLA(t *testing.T) {
+	body := `{"total": 5, "issues": [
+		{"key": "SD-1", "fields": {"customfield_10030": {"ongoingCycle": {"breached": true, "remainingTime": {"millis": -1000}}}}},
+		{"key": "SD-2", "fields": {"customfield_10030": {"ongoingCycle": {"breached": false, "remainingTime": {"millis": 600000}}}}},
+		{"key": "SD-3", "fields": {"customfield_10030": {"ongoingCycle": {"breached": false, "remainingTime": {"millis": 2700000}}}}},
+		{"key": "SD-4", "fields": {"customfield_10030": {"completedCycles": []}}},
+		{"key": "SD-5", "fields": {"customfield_10030": null}}
+	]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fields") != "customfield_10030" {
+			fmt.Fprint(w, `{"total": 1, "issues": [{"key": "SD-6", "fields": {}}]}`)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+	s := newEndpointScraper(&Configuration{BaseURL: srv.URL}, &http.Client{})
+
+	m := MetricConfiguration{Name: "sla", JQL: "project = SD", Type: metricTypeSLA, SLAField: "customfield_10030", AtRiskThresholds: []string{"30m", "1h"}}
+	require.NoError(t, validateMetricType(&m))
+	result, err := s.scrape(context.Background(), m)
+	require.NoError(t, err)
+	require.Equal(t, float64(1), result.Value)
+	require.Equal(t, map[string]float64{"30m": 1, "1h": 2}, result.Groups)
+
+	// A missing field results in a clear error.
+	m.SLAField = "customfield_99999"
+	_, err = s.scrape(context.Background(), m)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "SLA field customfield_99999 is not present on issue SD-6")
+	var scrapeErr *scrapeError
+	require.True(t, errors.As(err, &scrapeErr))
+	require.Equal(t, errorReasonDecode, scrapeErr.reason)
+}
+
+func TestSetupSLAGauges(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics := []MetricConfiguration{{Name: "sla", Help: "test", Type: metricTypeSLA, SLAField: "customfield_10030"}}
+	require.NoError(t, validateMetricType(&metrics[0]))
+	require.NoError(t, SetupGauges(reg, reg, metrics))
+	updateGauge(metrics[0], scrapeResult{Value: 2, Groups: map[string]float64{"30m": 3}}, nil)
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	names := []string{}
+	for _, f := range families {
+		names = append(names, f.GetName())
+	}
+	require.Contains(t, names, "jira_sla_breached_requests")
+	require.Contains(t, names, "jira_sla_at_risk_requests")
+}
+
+func TestValidateSLAMetric(t *testing.T) {
+	require.Error(t, validateMetricType(&MetricConfiguration{Type: metricTypeSLA}))
+	require.Error(t, validateMetricType(&MetricConfiguration{Type: metricTypeSLA, SLAField: "customfield_1", AtRiskThresholds: []string{"soon"}}))
+}
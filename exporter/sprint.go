@@ -0,0 +1,69 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const metricTypeSprint = "sprint"
+
+func validateSprintMetric(m *MetricConfiguration) error {
+	if err := validateBoard(m); err != nil {
+		return err
+	}
+	if len(m.Aggregates) > 0 {
+		return errors.New("aggregates are not supported for sprint metrics")
+	}
+	if m.DiscoverProjects != nil {
+		return errors.New("sprint metrics and discoverProjects must not be used together")
+	}
+	return nil
+}
+
+// activeSprints returns the IDs of the active sprints of the given board.
+// Boards with parallel sprints can have more than one.
+func (s *endpointScraper) activeSprints(ctx context.Context, board int) ([]int, error) {
+	var sprints struct {
+		Values []struct {
+			ID int `json:"id"`
+		} `json:"values"`
+	}
+	u := agileURL(s.cfg, fmt.Sprintf("board/%d/sprint", board), url.Values{"state": {"active"}})
+	if err := s.getJSON(ctx, u, &sprints); err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(sprints.Values))
+	for _, sprint := range sprints.Values {
+		ids = append(ids, sprint.ID)
+	}
+	return ids, nil
+}
+
+// scrapeSprint counts the issues of the active sprints of the metric's board
+// that match its JQL, grouped like a count metric. The sprints are resolved
+// on every scrape so that the metric follows the board to the next sprint.
+// Without an active sprint there is nothing to count.
+func (s *endpointScraper) scrapeSprint(ctx context.Context, m MetricConfiguration) (scrapeResult, error) {
+	board, err := s.boardID(ctx, m.Board)
+	if err != nil {
+		return scrapeResult{}, err
+	}
+	sprints, err := s.activeSprints(ctx, board)
+	if err != nil || len(sprints) == 0 {
+		return scrapeResult{}, err
+	}
+	ids := make([]string, 0, len(sprints))
+	for _, id := range sprints {
+		ids = append(ids, strconv.Itoa(id))
+	}
+	sprintMetric := m
+	sprintMetric.Type = metricTypeCount
+	sprintMetric.ParsedTimeout = 0
+	sprintMetric.JQL = addCondition(m.JQL, fmt.Sprintf("sprint in (%s)", strings.Join(ids, ", ")))
+	return s.scrape(ctx, sprintMetric)
+}
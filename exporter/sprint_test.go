@@ -0,0 +1,82 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSprint(t *testing.T) {
+	sprints := atomic.Value{}
+	sprints.Store(`[{"id": 12, "name": "Sprint 12", "state": "active"}]`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/agile/1.0/board/7/sprint":
+			require.Equal(t, "active", r.URL.Query().Get("state"))
+			fmt.Fprintf(w, `{"isLast": true, "values": %s}`, sprints.Load())
+		case "/rest/api/2/search":
+			require.Equal(t, "(issuetype = Bug) AND sprint in (12, 13)", r.URL.Query().Get("jql"))
+			require.Equal(t, "status", r.URL.Query().Get("fields"))
+			fmt.Fprint(w, `{"total": 3, "issues": [
+				{"key": "A-1", "fields": {"status": {"statusCategory": {"key": "new"}}}},
+				{"key": "A-2", "fields": {"status": {"statusCategory": {"key": "done"}}}},
+				{"key": "A-3", "fields": {"status": {"statusCategory": {"key": "done"}}}}
+			]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	cfg, err := LoadConfiguration(writeConfig(t, fmt.Sprintf(`
+baseURL: %s
+metrics:
+  - name: sprint_bugs
+    help: Bugs in the active sprint
+    type: sprint
+    board:
+      id: 7
+    jql: issuetype = Bug
+    groupBy: statusCategory
+`, srv.URL)), false)
+	require.NoError(t, err)
+	reg := prometheus.NewRegistry()
+	require.NoError(t, SetupGauges(reg, reg, cfg.Metrics))
+	m := cfg.Metrics[0]
+	s := newEndpointScraper(cfg, &http.Client{})
+
+	// Without an active sprint nothing is counted.
+	sprints.Store(`[]`)
+	result, err := s.scrape(context.Background(), m)
+	require.NoError(t, err)
+	require.Equal(t, scrapeResult{}, result)
+
+	sprints.Store(`[{"id": 12, "name": "Sprint 12"}, {"id": 13, "name": "Sprint 13"}]`)
+	result, err = s.scrape(context.Background(), m)
+	require.NoError(t, err)
+	updateGauge(m, result, nil)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP jira_sprint_bugs Bugs in the active sprint
+# TYPE jira_sprint_bugs gauge
+jira_sprint_bugs{board="7",status_category="done"} 2
+jira_sprint_bugs{board="7",status_category="todo"} 1
+`), "jira_sprint_bugs"))
+}
+
+func TestValidateSprintMetric(t *testing.T) {
+	m := MetricConfiguration{Type: metricTypeSprint}
+	require.Error(t, validateSprintMetric(&m))
+	m.Board = &boardConfiguration{Name: "Team Board"}
+	require.NoError(t, validateSprintMetric(&m))
+	require.Equal(t, map[string]string{boardLabel: "Team Board"}, m.Labels)
+	m.Aggregates = []string{"avg"}
+	require.Error(t, validateSprintMetric(&m))
+}
@@ -0,0 +1,97 @@
+package exporter
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// States of a metric as exported by jira_scrape_state. Every metric starts
+// as pending and switches between ok and failing after its first scrape.
+const (
+	scrapeStatePending = 0
+	scrapeStateOK      = 1
+	scrapeStateFailing = 2
+)
+
+// scrapeStates keeps the state of every metric. It is updated concurrently
+// by all workers.
+type scrapeStates struct {
+	mu     sync.Mutex
+	states map[string]int
+	gauge  *prometheus.GaugeVec
+}
+
+// setupScrapeStates registers the jira_scrape_state gauge with all metrics
+// pending.
+func setupScrapeStates(registry prometheus.Registerer, metrics []MetricConfiguration) (*scrapeStates, error) {
+	s := &scrapeStates{
+		states: make(map[string]int, len(metrics)),
+		gauge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "jira_scrape_state",
+			Help: "State of the metric: 0 while its first scrape is pending, 1 if the last scrape succeeded, 2 if it failed",
+		}, []string{"metric"}),
+	}
+	if err := registry.Register(s.gauge); err != nil {
+		return nil, err
+	}
+	for _, m := range metrics {
+		s.states[metricID(m)] = scrapeStatePending
+		s.gauge.WithLabelValues(metricID(m)).Set(scrapeStatePending)
+	}
+	return s, nil
+}
+
+// update records the outcome of a scrape of the given metric. A nil
+// scrapeStates ignores all updates.
+func (s *scrapeStates) update(metric string, err error) {
+	if s == nil {
+		return
+	}
+	state := scrapeStateOK
+	if err != nil {
+		state = scrapeStateFailing
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[metric] = state
+	s.gauge.WithLabelValues(metric).Set(float64(state))
+}
+
+// pending returns the sorted IDs of all metrics that haven't completed a
+// scrape yet.
+func (s *scrapeStates) pending() []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []string
+	for metric, state := range s.states {
+		if state == scrapeStatePending {
+			result = append(result, metric)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// warnPendingAfter logs all metrics still pending once the deadline has
+// passed.
+func warnPendingAfter(ctx context.Context, log *logrus.Logger, s *scrapeStates, deadline time.Duration) {
+	timer := time.NewTimer(deadline)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return
+	}
+	if pending := s.pending(); len(pending) > 0 {
+		log.Warnf("%d metrics are still pending %s after startup: %s", len(pending), deadline, strings.Join(pending, ", "))
+	}
+}
@@ -0,0 +1,44 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrapeStates(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	states, err := setupScrapeStates(reg, []MetricConfiguration{{Name: "a"}, {Name: "b"}, {Name: "c"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c"}, states.pending())
+
+	states.update("a", nil)
+	states.update("b", errors.New("failed"))
+	require.Equal(t, []string{"c"}, states.pending())
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP jira_scrape_state State of the metric: 0 while its first scrape is pending, 1 if the last scrape succeeded, 2 if it failed
+# TYPE jira_scrape_state gauge
+jira_scrape_state{metric="a"} 1
+jira_scrape_state{metric="b"} 2
+jira_scrape_state{metric="c"} 0
+`)))
+
+	log, hook := logtest.NewNullLogger()
+	warnPendingAfter(context.Background(), log, states, time.Millisecond)
+	require.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+	require.Contains(t, hook.LastEntry().Message, "1 metrics are still pending")
+	require.Contains(t, hook.LastEntry().Message, ": c")
+
+	hook.Reset()
+	states.update("c", nil)
+	warnPendingAfter(context.Background(), log, states, time.Millisecond)
+	require.Empty(t, hook.AllEntries())
+}
@@ -0,0 +1,126 @@
+package exporter
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	statsdTagsDatadog = "datadog"
+	statsdTagsNone    = "none"
+)
+
+// statsdTagReplacer replaces the characters that separate tags, fields and
+// lines in the DogStatsD protocol inside tag values.
+var statsdTagReplacer = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_", "\r", "_")
+
+// statsdErrorLogInterval limits how often failed writes are logged.
+const statsdErrorLogInterval = time.Minute
+
+type statsdConfiguration struct {
+	// Address of the StatsD agent, e.g. 127.0.0.1:8125. Nothing is sent if
+	// empty.
+	Address string `yaml:"address"`
+	Prefix  string `yaml:"prefix"`
+	// TagsFormat is either "datadog" (the default) or "none".
+	TagsFormat string `yaml:"tagsFormat"`
+}
+
+// statsdClient sends gauges via UDP. A nil client silently drops all values.
+type statsdClient struct {
+	log        *logrus.Logger
+	conn       net.Conn
+	prefix     string
+	tagsFormat string
+	now        func() time.Time
+
+	mu            sync.Mutex
+	lastErrorLog  time.Time
+	suppressedErr int
+}
+
+func newStatsDClient(log *logrus.Logger, cfg statsdConfiguration) (*statsdClient, error) {
+	if cfg.Address == "" {
+		return nil, nil
+	}
+	conn, err := net.Dial("udp", cfg.Address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to StatsD at %s", cfg.Address)
+	}
+	tagsFormat := cfg.TagsFormat
+	if tagsFormat == "" {
+		tagsFormat = statsdTagsDatadog
+	}
+	return &statsdClient{
+		log:        log,
+		conn:       conn,
+		prefix:     cfg.Prefix,
+		tagsFormat: tagsFormat,
+		now:        time.Now,
+	}, nil
+}
+
+func validateStatsDConfiguration(cfg statsdConfiguration) error {
+	switch cfg.TagsFormat {
+	case "", statsdTagsDatadog, statsdTagsNone:
+		return nil
+	}
+	return errors.Errorf("unsupported statsd.tagsFormat %q", cfg.TagsFormat)
+}
+
+// formatGauge renders a single gauge line.
+func (c *statsdClient) formatGauge(name string, value float64, tags map[string]string) string {
+	line := fmt.Sprintf("%s%s:%s|g", c.prefix, name, strconv.FormatFloat(value, 'f', -1, 64))
+	if c.tagsFormat != statsdTagsDatadog || len(tags) == 0 {
+		return line
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+":"+statsdTagReplacer.Replace(tags[k]))
+	}
+	return line + "|#" + strings.Join(pairs, ",")
+}
+
+// gauge sends a single gauge value. Write errors are logged at most once per
+// statsdErrorLogInterval.
+func (c *statsdClient) gauge(name string, value float64, tags map[string]string) {
+	if c == nil {
+		return
+	}
+	if _, err := c.conn.Write([]byte(c.formatGauge(name, value, tags))); err != nil {
+		c.logError(err)
+	}
+}
+
+func (c *statsdClient) logError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if now.Sub(c.lastErrorLog) < statsdErrorLogInterval {
+		c.suppressedErr++
+		return
+	}
+	c.log.WithError(err).WithField("suppressed", c.suppressedErr).Warn("Failed to send value to StatsD")
+	c.lastErrorLog = now
+	c.suppressedErr = 0
+}
+
+func (c *statsdClient) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
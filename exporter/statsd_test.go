@@ -0,0 +1,49 @@
+package exporter
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsDClient(t *testing.T) {
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+
+	t.Run("disabled", func(t *testing.T) {
+		client, err := newStatsDClient(log, statsdConfiguration{})
+		require.NoError(t, err)
+		require.Nil(t, client)
+		// Sending to a disabled client is a no-op.
+		client.gauge("test", 1, nil)
+		require.NoError(t, client.Close())
+	})
+
+	t.Run("send", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+		client, err := newStatsDClient(log, statsdConfiguration{Address: conn.LocalAddr().String(), Prefix: "jira."})
+		require.NoError(t, err)
+		defer client.Close()
+
+		client.gauge("backlog", 42, map[string]string{"team": "a", "env": "prod"})
+		buf := make([]byte, 1024)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		require.Equal(t, "jira.backlog:42|g|#env:prod,team:a", string(buf[:n]))
+	})
+
+	t.Run("format", func(t *testing.T) {
+		client := &statsdClient{tagsFormat: statsdTagsNone}
+		require.Equal(t, "backlog:1.5|g", client.formatGauge("backlog", 1.5, map[string]string{"team": "a"}))
+		client.tagsFormat = statsdTagsDatadog
+		require.Equal(t, "backlog:1.5|g", client.formatGauge("backlog", 1.5, nil))
+		// Values can't break out of their tag, field or line.
+		require.Equal(t, "backlog:1|g|#team:a_b_c_d_e", client.formatGauge("backlog", 1, map[string]string{"team": "a,b|c#d\ne"}))
+	})
+}
@@ -0,0 +1,55 @@
+package exporter
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Values of the subtasks setting of a metric.
+const (
+	subtasksInclude  = "include"
+	subtasksExclude  = "exclude"
+	subtasksSeparate = "separate"
+)
+
+// issueType is the type of an issue as far as it is needed to tell
+// sub-tasks apart.
+type issueType struct {
+	Subtask bool `json:"subtask"`
+}
+
+// filtersSubtasks reports whether sub-tasks are not counted as part of the
+// metric itself.
+func filtersSubtasks(m MetricConfiguration) bool {
+	return m.Subtasks == subtasksExclude || m.Subtasks == subtasksSeparate
+}
+
+func validateSubtasks(m MetricConfiguration) error {
+	switch m.Subtasks {
+	case "", subtasksInclude, subtasksExclude:
+		return nil
+	case subtasksSeparate:
+		if m.Type != "" && m.Type != metricTypeCount {
+			return errors.Errorf("subtasks: separate is not supported for %s metrics", m.Type)
+		}
+		if len(m.Variables) > 0 {
+			return errors.New("subtasks: separate is not supported together with variables")
+		}
+		return nil
+	}
+	return errors.Errorf("unsupported subtasks %q", m.Subtasks)
+}
+
+// scrapeCount counts the issues matching the JQL of a metric that doesn't
+// include sub-tasks. Contrary to plain metrics this requires fetching all
+// issues.
+func (s *endpointScraper) scrapeCount(ctx context.Context, m MetricConfiguration) (scrapeResult, error) {
+	count := 0.0
+	result, err := s.fetchIssues(ctx, m, func(issue) error {
+		count++
+		return nil
+	})
+	result.Value = count
+	return result, err
+}
@@ -0,0 +1,56 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubtasks(t *testing.T) {
+	var fields string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fields = r.URL.Query().Get("fields")
+		fmt.Fprint(w, `{"total": 4, "issues": [
+			{"fields": {"issuetype": {"subtask": false}, "priority": {"name": "Major"}}},
+			{"fields": {"issuetype": {"subtask": true}, "priority": {"name": "Major"}}},
+			{"fields": {"issuetype": {"subtask": true}, "priority": {"name": "Minor"}}},
+			{"fields": {"issuetype": {"subtask": false}, "priority": {"name": "Minor"}}}
+		]}`)
+	}))
+	defer srv.Close()
+	s := newEndpointScraper(&Configuration{BaseURL: srv.URL}, &http.Client{})
+
+	result, err := s.scrape(context.Background(), MetricConfiguration{Name: "test", JQL: "project = TEST", GroupBy: "priority", Subtasks: subtasksExclude})
+	require.NoError(t, err)
+	require.Equal(t, "priority,issuetype", fields)
+	require.Equal(t, map[string]float64{"Major": 1, "Minor": 1}, result.Groups)
+
+	reg := prometheus.NewRegistry()
+	metrics := []MetricConfiguration{{Name: "test", Help: "test", JQL: "project = TEST", Subtasks: subtasksSeparate}}
+	require.NoError(t, SetupGauges(reg, reg, metrics))
+	result, err = s.scrape(context.Background(), metrics[0])
+	require.NoError(t, err)
+	require.Equal(t, "issuetype", fields)
+	updateGauge(metrics[0], result, nil)
+	require.Equal(t, float64(2), testutil.ToFloat64(metrics[0].Gauge))
+	require.Equal(t, float64(2), testutil.ToFloat64(metrics[0].SubtaskGauge))
+	require.Equal(t, 1, testutil.CollectAndCount(reg, "jira_test_subtasks"))
+
+	result, err = s.scrape(context.Background(), MetricConfiguration{Name: "test", JQL: "project = TEST"})
+	require.NoError(t, err)
+	require.Equal(t, "", fields)
+	require.Equal(t, float64(4), result.Value)
+}
+
+func TestValidateSubtasks(t *testing.T) {
+	require.NoError(t, validateSubtasks(MetricConfiguration{Subtasks: subtasksSeparate, GroupBy: "priority"}))
+	require.Error(t, validateSubtasks(MetricConfiguration{Subtasks: "only"}))
+	require.Error(t, validateSubtasks(MetricConfiguration{Subtasks: subtasksSeparate, Type: metricTypeTimeSpent}))
+	require.Error(t, validateSubtasks(MetricConfiguration{Subtasks: subtasksSeparate, Variables: map[string][]string{"project": {"A"}}}))
+}
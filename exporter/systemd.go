@@ -0,0 +1,59 @@
+package exporter
+
+import (
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// systemdListenFDStart is the first file descriptor passed by systemd's
+// socket activation.
+const systemdListenFDStart = 3
+
+// systemdListener returns the listener passed by systemd's socket activation
+// or nil if the process hasn't been socket activated.
+func systemdListener() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	fds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || fds < 1 {
+		return nil, nil
+	}
+	// Child processes must not pick up the sockets as well.
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+	f := os.NewFile(systemdListenFDStart, "LISTEN_FD_3")
+	defer f.Close()
+	l, err := net.FileListener(f)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to use socket passed by systemd")
+	}
+	return l, nil
+}
+
+// sdNotify sends the given state like READY=1 to systemd. Nothing is sent if
+// NOTIFY_SOCKET is not set.
+func sdNotify(state string) error {
+	name := os.Getenv("NOTIFY_SOCKET")
+	if name == "" {
+		return nil
+	}
+	// Abstract sockets are prefixed with @.
+	if name[0] == '@' {
+		name = "\x00" + name[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: name, Net: "unixgram"})
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to systemd")
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return errors.Wrap(err, "failed to notify systemd")
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package exporter
+
+import (
+	"net"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSdNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	require.NoError(t, sdNotify("READY=1"))
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", path)
+	require.NoError(t, sdNotify("READY=1"))
+	buf := make([]byte, 64)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "READY=1", string(buf[:n]))
+}
+
+func TestSystemdListenerWithoutActivation(t *testing.T) {
+	t.Setenv("LISTEN_PID", "")
+	t.Setenv("LISTEN_FDS", "")
+	l, err := systemdListener()
+	require.NoError(t, err)
+	require.Nil(t, l)
+
+	// Sockets meant for another process are ignored.
+	t.Setenv("LISTEN_PID", strconv.Itoa(1))
+	t.Setenv("LISTEN_FDS", "1")
+	l, err = systemdListener()
+	require.NoError(t, err)
+	require.Nil(t, l)
+}
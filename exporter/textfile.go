@@ -0,0 +1,72 @@
+package exporter
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	prom_dto "github.com/prometheus/client_model/go"
+	"github.com/sirupsen/logrus"
+)
+
+// textfileGatherer leaves out the Go runtime and process metrics of the
+// wrapped gatherer. The textfile collector would reject them as they clash
+// with node_exporter's own.
+type textfileGatherer struct {
+	prometheus.Gatherer
+}
+
+func (g textfileGatherer) Gather() ([]*prom_dto.MetricFamily, error) {
+	families, err := g.Gatherer.Gather()
+	filtered := families[:0]
+	for _, f := range families {
+		if !strings.HasPrefix(f.GetName(), "go_") && !strings.HasPrefix(f.GetName(), "process_") {
+			filtered = append(filtered, f)
+		}
+	}
+	return filtered, err
+}
+
+// textfileInterval returns how often the textfile output is written, which
+// is the interval of the most frequently scraped metric, including the
+// intervals of schedules during business hours. Without metrics it falls
+// back to the default metric interval.
+func textfileInterval(metrics []MetricConfiguration) time.Duration {
+	interval := 5 * time.Minute
+	for i, m := range metrics {
+		if i == 0 || m.ParsedInterval < interval {
+			interval = m.ParsedInterval
+		}
+		if m.Schedule != nil && m.Schedule.parsedInterval < interval {
+			interval = m.Schedule.parsedInterval
+		}
+	}
+	return interval
+}
+
+// writeTextfiles periodically writes everything collected by the gatherer
+// to path in the text format, e.g. for node_exporter's textfile collector.
+// The file is replaced atomically so that readers never see a partial file.
+// It is written right away, so that it exists before the first tick, and
+// once more when the context is cancelled.
+func writeTextfiles(ctx context.Context, log *logrus.Logger, path string, gatherer prometheus.Gatherer, interval time.Duration) {
+	writeTextfile(log, path, gatherer)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			writeTextfile(log, path, gatherer)
+			return
+		case <-ticker.C:
+			writeTextfile(log, path, gatherer)
+		}
+	}
+}
+
+func writeTextfile(log *logrus.Logger, path string, gatherer prometheus.Gatherer) {
+	if err := prometheus.WriteToTextfile(path, textfileGatherer{gatherer}); err != nil {
+		log.WithError(err).Warnf("Failed to write metrics to %s", path)
+	}
+}
@@ -0,0 +1,63 @@
+package exporter
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTextfiles(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "jira_open_issues", Help: "Open issues"})
+	reg.MustRegister(gauge, collectors.NewGoCollector())
+	gauge.Set(3)
+	path := filepath.Join(t.TempDir(), "jira.prom")
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		writeTextfiles(ctx, log, path, reg, time.Hour)
+	}()
+	// The file is written without waiting for the first tick.
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+
+	// The last state is written on shutdown.
+	gauge.Set(5)
+	cancel()
+	<-done
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, `# HELP jira_open_issues Open issues
+# TYPE jira_open_issues gauge
+jira_open_issues 5
+`, string(data))
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "temporary files must be renamed")
+}
+
+func TestTextfileInterval(t *testing.T) {
+	require.Equal(t, 5*time.Minute, textfileInterval(nil))
+	require.Equal(t, time.Minute, textfileInterval([]MetricConfiguration{
+		{ParsedInterval: 10 * time.Minute},
+		{ParsedInterval: time.Minute},
+	}))
+	require.Equal(t, 30*time.Second, textfileInterval([]MetricConfiguration{
+		{ParsedInterval: 10 * time.Minute, Schedule: &scheduleConfiguration{parsedInterval: 30 * time.Second}},
+		{ParsedInterval: time.Minute},
+	}))
+}
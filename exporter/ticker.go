@@ -0,0 +1,70 @@
+package exporter
+
+import "time"
+
+// scrapeTicker delivers the ticks on which a worker scrapes its metric.
+type scrapeTicker struct {
+	C    <-chan time.Time
+	stop func()
+}
+
+func (t *scrapeTicker) Stop() {
+	t.stop()
+}
+
+// newScrapeTicker returns a ticker firing every interval of the metric.
+// Ticks of aligned metrics fall on the multiples of the interval instead of
+// being relative to the start of the worker. For metrics with a schedule,
+// the interval is picked anew on every tick.
+func newScrapeTicker(m MetricConfiguration) *scrapeTicker {
+	if !m.Align && m.Schedule == nil {
+		t := time.NewTicker(m.ParsedInterval)
+		return &scrapeTicker{C: t.C, stop: t.Stop}
+	}
+	return newTimedTicker(func(now time.Time) time.Time {
+		return nextScrape(m, now)
+	})
+}
+
+// nextScrape returns the time of the metric's next scheduled scrape after
+// now.
+func nextScrape(m MetricConfiguration, now time.Time) time.Time {
+	interval := intervalAt(m, now)
+	if m.Align {
+		return nextBoundary(now, interval)
+	}
+	return now.Add(interval)
+}
+
+// nextBoundary returns the first multiple of interval after now. Multiples
+// are counted from the zero time, so intervals dividing a day are aligned to
+// midnight UTC, e.g. 1h to the full hour.
+func nextBoundary(now time.Time, interval time.Duration) time.Time {
+	return now.Truncate(interval).Add(interval)
+}
+
+// newTimedTicker returns a ticker firing at the times returned by next,
+// which is asked again after every tick. As the next tick is always derived
+// from the current time, the ticks don't drift. Like time.Ticker it drops
+// ticks for slow receivers.
+func newTimedTicker(next func(now time.Time) time.Time) *scrapeTicker {
+	c := make(chan time.Time, 1)
+	stop := make(chan struct{})
+	go func() {
+		timer := time.NewTimer(time.Until(next(time.Now())))
+		defer timer.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case tick := <-timer.C:
+				select {
+				case c <- tick:
+				default:
+				}
+				timer.Reset(time.Until(next(time.Now())))
+			}
+		}
+	}()
+	return &scrapeTicker{C: c, stop: func() { close(stop) }}
+}
@@ -0,0 +1,32 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextBoundary(t *testing.T) {
+	now := time.Date(2024, 3, 5, 14, 37, 12, 0, time.UTC)
+	require.Equal(t, time.Date(2024, 3, 5, 15, 0, 0, 0, time.UTC), nextBoundary(now, time.Hour))
+	require.Equal(t, time.Date(2024, 3, 5, 14, 45, 0, 0, time.UTC), nextBoundary(now, 15*time.Minute))
+	require.Equal(t, time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC), nextBoundary(now, 24*time.Hour))
+	// A time on a boundary moves on to the next one.
+	require.Equal(t, time.Date(2024, 3, 5, 16, 0, 0, 0, time.UTC), nextBoundary(time.Date(2024, 3, 5, 15, 0, 0, 0, time.UTC), time.Hour))
+}
+
+func TestAlignedScrapeTicker(t *testing.T) {
+	interval := 50 * time.Millisecond
+	ticker := newScrapeTicker(MetricConfiguration{ParsedInterval: interval, Align: true})
+	defer ticker.Stop()
+	for i := 0; i < 3; i++ {
+		select {
+		case tick := <-ticker.C:
+			offset := tick.Sub(tick.Truncate(interval))
+			require.Less(t, offset, 20*time.Millisecond, "tick %d at offset %s", i, offset)
+		case <-time.After(time.Second):
+			t.Fatal("no tick")
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package exporter
+
+import (
+	"context"
+)
+
+// scrapeTimeSpent sums up the time logged on all matching issues in
+// seconds, either in total or per group. Only the aggregated timespent field
+// is requested so that the potentially large worklogs are never transferred.
+func (s *endpointScraper) scrapeTimeSpent(ctx context.Context, m MetricConfiguration) (scrapeResult, error) {
+	var g grouper
+	var groups map[string]float64
+	if m.GroupBy != "" {
+		var err error
+		g, err = lookupGrouper(m.GroupBy)
+		if err != nil {
+			return scrapeResult{}, &scrapeError{reason: errorReasonRequest, err: err}
+		}
+		groups = make(map[string]float64)
+	}
+	sum := 0.0
+	result, err := s.fetchIssues(ctx, m, func(i issue) error {
+		var spent float64
+		if i.Fields.TimeSpent != nil {
+			spent = float64(*i.Fields.TimeSpent)
+		}
+		sum += spent
+		if groups == nil {
+			return nil
+		}
+		for _, value := range groupValues(g, i, m) {
+			groups[value] += spent
+		}
+		return nil
+	})
+	result.Value = sum
+	result.Groups = topGroups(groups, m.TopN)
+	return result, err
+}
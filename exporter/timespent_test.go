@@ -0,0 +1,36 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrapeTimeSpent(t *testing.T) {
+	var fields string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fields = r.URL.Query().Get("fields")
+		fmt.Fprint(w, `{"total": 3, "issues": [
+			{"fields": {"timespent": 3600, "assignee": {"displayName": "Jane"}}},
+			{"fields": {"timespent": null, "assignee": {"displayName": "Jane"}}},
+			{"fields": {"timespent": 1800}}
+		]}`)
+	}))
+	defer srv.Close()
+	s := newEndpointScraper(&Configuration{BaseURL: srv.URL}, &http.Client{})
+
+	result, err := s.scrape(context.Background(), MetricConfiguration{Name: "test", JQL: "project = TEST", Type: metricTypeTimeSpent})
+	require.NoError(t, err)
+	require.Equal(t, "timespent", fields)
+	require.Equal(t, float64(5400), result.Value)
+	require.Nil(t, result.Groups)
+
+	result, err = s.scrape(context.Background(), MetricConfiguration{Name: "test", JQL: "project = TEST", Type: metricTypeTimeSpent, GroupBy: "assignee"})
+	require.NoError(t, err)
+	require.Equal(t, "timespent,assignee", fields)
+	require.Equal(t, map[string]float64{"Jane": 3600, "unassigned": 1800}, result.Groups)
+}
@@ -0,0 +1,104 @@
+package exporter
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+type tlsConfiguration struct {
+	// CAFile is a PEM file with additional certificate authorities that
+	// are trusted for the server certificate.
+	CAFile string `yaml:"caFile"`
+	// CertFile and KeyFile are used for client certificate authentication.
+	CertFile           string `yaml:"certFile"`
+	KeyFile            string `yaml:"keyFile"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+	// MinVersion is the minimum TLS version, e.g. "1.2".
+	MinVersion string `yaml:"minVersion"`
+	// CipherSuites limits the cipher suites of TLS 1.2 and below to the
+	// given names, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Those of TLS
+	// 1.3 can't be configured.
+	CipherSuites []string `yaml:"cipherSuites"`
+	// Renegotiation is either "never" (the default), "once" or "freely".
+	Renegotiation string `yaml:"renegotiation"`
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var tlsRenegotiation = map[string]tls.RenegotiationSupport{
+	"never":  tls.RenegotiateNever,
+	"once":   tls.RenegotiateOnceAsClient,
+	"freely": tls.RenegotiateFreelyAsClient,
+}
+
+// cipherSuiteID returns the ID of the cipher suite with the given name.
+func cipherSuiteID(name string) (uint16, error) {
+	for _, suites := range [][]*tls.CipherSuite{tls.CipherSuites(), tls.InsecureCipherSuites()} {
+		for _, s := range suites {
+			if s.Name == name {
+				return s.ID, nil
+			}
+		}
+	}
+	return 0, errors.Errorf("unknown cipher suite %q", name)
+}
+
+// build creates a *tls.Config from the configuration. It returns nil if no
+// TLS settings have been configured.
+func (c tlsConfiguration) build() (*tls.Config, error) {
+	if c.CAFile == "" && c.CertFile == "" && c.KeyFile == "" && !c.InsecureSkipVerify &&
+		c.MinVersion == "" && len(c.CipherSuites) == 0 && c.Renegotiation == "" {
+		return nil, nil
+	}
+	cfg := &tls.Config{
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if c.MinVersion != "" {
+		version, ok := tlsVersions[c.MinVersion]
+		if !ok {
+			return nil, errors.Errorf("unsupported minVersion %q (supported: 1.0, 1.1, 1.2, 1.3)", c.MinVersion)
+		}
+		cfg.MinVersion = version
+	}
+	for _, name := range c.CipherSuites {
+		id, err := cipherSuiteID(name)
+		if err != nil {
+			return nil, err
+		}
+		cfg.CipherSuites = append(cfg.CipherSuites, id)
+	}
+	if c.Renegotiation != "" {
+		renegotiation, ok := tlsRenegotiation[c.Renegotiation]
+		if !ok {
+			return nil, errors.Errorf("unsupported renegotiation %q (supported: never, once, freely)", c.Renegotiation)
+		}
+		cfg.Renegotiation = renegotiation
+	}
+	if c.CAFile != "" {
+		data, err := ioutil.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", c.CAFile)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errors.Errorf("no certificates found in %s", c.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load client certificate")
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,46 @@
+package exporter
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// issuesTotal keeps the latest total of every metric and exports their sum.
+// It is updated concurrently by all workers.
+type issuesTotal struct {
+	mu     sync.Mutex
+	totals map[string]uint64
+	gauge  prometheus.Gauge
+}
+
+// setupIssuesTotal registers the jira_issues_total gauge.
+func setupIssuesTotal(registry prometheus.Registerer) (*issuesTotal, error) {
+	t := &issuesTotal{
+		totals: make(map[string]uint64),
+		gauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "jira_issues_total",
+			Help: "Sum of the latest totals of all configured metrics",
+		}),
+	}
+	if err := registry.Register(t.gauge); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// update records the latest total of the given metric and updates the sum.
+// A nil issuesTotal ignores all updates.
+func (t *issuesTotal) update(metric string, total uint64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.totals[metric] = total
+	sum := uint64(0)
+	for _, v := range t.totals {
+		sum += v
+	}
+	t.gauge.Set(float64(sum))
+}
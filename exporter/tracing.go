@@ -0,0 +1,108 @@
+package exporter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/zerok/jiravars"
+
+// tracingEnabled reports whether traces should be exported, either because an
+// endpoint has been passed explicitly or because one has been configured
+// using the standard OTEL_* environment variables.
+func tracingEnabled(endpoint string) bool {
+	return endpoint != "" || os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// setupTracing installs a global tracer provider that exports spans via
+// OTLP/HTTP. The returned function flushes and stops the exporter. If tracing
+// is not enabled, the global no-op provider is kept in place.
+func setupTracing(ctx context.Context, endpoint string) (func(context.Context) error, error) {
+	if !tracingEnabled(endpoint) {
+		return func(context.Context) error { return nil }, nil
+	}
+	var opts []otlptracehttp.Option
+	if endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	res, err := newResource(ctx)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// newResource describes the exporter process for OpenTelemetry. Attributes
+// set via OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES take precedence.
+func newResource(ctx context.Context) (*resource.Resource, error) {
+	return resource.New(ctx,
+		resource.WithAttributes(
+			attribute.String("service.name", "jiravars"),
+			attribute.String("service.version", version),
+		),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+}
+
+// startScrapeSpan starts the span covering a single scrape of the given
+// metric.
+func startScrapeSpan(ctx context.Context, m MetricConfiguration) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, "scrape", trace.WithAttributes(
+		attribute.String("jira.metric", m.Name),
+		attribute.String("jira.jql_hash", jqlHash(m.JQL)),
+	))
+}
+
+// jqlHash returns a short, stable identifier for a JQL query so that it can
+// be attached to spans without leaking the query itself.
+func jqlHash(jql string) string {
+	sum := sha256.Sum256([]byte(jql))
+	return hex.EncodeToString(sum[:8])
+}
+
+// endScrapeSpan records the outcome of a scrape on its span and ends it.
+func endScrapeSpan(span trace.Span, result scrapeResult, err error) {
+	defer span.End()
+	statusCode := http.StatusOK
+	if err != nil {
+		var scrapeErr *scrapeError
+		statusCode = 0
+		if errors.As(err, &scrapeErr) {
+			statusCode = scrapeErr.statusCode
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, classifyError(err))
+	} else {
+		span.SetAttributes(
+			attribute.Int("jira.page_count", result.Pages),
+			attribute.Int64("jira.issue_count", int64(result.Total)),
+		)
+	}
+	if statusCode != 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+	}
+}
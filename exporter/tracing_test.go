@@ -0,0 +1,76 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestScrapeSpans(t *testing.T) {
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider := otel.GetTracerProvider()
+	previousPropagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	}()
+
+	reg := prometheus.NewRegistry()
+	ctx, cancel := context.WithCancel(context.Background())
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		fmt.Fprint(w, `{"total": 7}`)
+		cancel()
+	}))
+	defer srv.Close()
+	cfg := &Configuration{
+		BaseURL: srv.URL,
+		Metrics: []MetricConfiguration{
+			{
+				Name:           "test",
+				Help:           "test",
+				JQL:            "project = TEST",
+				ParsedInterval: time.Second,
+			},
+		},
+	}
+	require.NoError(t, SetupGauges(reg, reg, cfg.Metrics))
+	httpClient := &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+	NewScraper(log, cfg, httpClient).Run(ctx)
+
+	var scrapeSpan sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "scrape" {
+			scrapeSpan = span
+		}
+	}
+	require.NotNil(t, scrapeSpan)
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, attr := range scrapeSpan.Attributes() {
+		attrs[attr.Key] = attr.Value
+	}
+	require.Equal(t, "test", attrs["jira.metric"].AsString())
+	require.Equal(t, jqlHash("project = TEST"), attrs["jira.jql_hash"].AsString())
+	require.Equal(t, int64(7), attrs["jira.issue_count"].AsInt64())
+	require.Equal(t, int64(http.StatusOK), attrs["http.response.status_code"].AsInt64())
+	require.Contains(t, traceparent, scrapeSpan.SpanContext().TraceID().String())
+}
@@ -0,0 +1,41 @@
+package exporter
+
+import (
+	"sync"
+)
+
+// scrapeTrigger wakes up all workers for an immediate scrape, e.g. on
+// SIGUSR1. Every worker selects on the channel returned by wait, which is
+// closed and replaced on each fire. As every worker scrapes sequentially,
+// a trigger never causes overlapping requests for the same metric.
+type scrapeTrigger struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+func newScrapeTrigger() *scrapeTrigger {
+	return &scrapeTrigger{ch: make(chan struct{})}
+}
+
+// wait returns a channel that is closed on the next fire. A nil
+// scrapeTrigger returns a nil channel which blocks forever.
+func (t *scrapeTrigger) wait() <-chan struct{} {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.ch
+}
+
+// fire wakes up all workers currently waiting. Firing a nil scrapeTrigger
+// does nothing.
+func (t *scrapeTrigger) fire() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	close(t.ch)
+	t.ch = make(chan struct{})
+}
@@ -0,0 +1,51 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrapeTrigger(t *testing.T) {
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	trigger := newScrapeTrigger()
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&requests, 1) {
+		case 1:
+			// The worker is busy, so the trigger results in another scrape
+			// once this one is done.
+			trigger.fire()
+		default:
+			cancel()
+		}
+		fmt.Fprint(w, `{"total": 1}`)
+	}))
+	defer srv.Close()
+	cfg := &Configuration{
+		BaseURL:       srv.URL,
+		scrapeTrigger: trigger,
+		Metrics: []MetricConfiguration{
+			{
+				Name:           "test",
+				Help:           "test",
+				JQL:            "project = TEST",
+				ParsedInterval: time.Hour,
+			},
+		},
+	}
+	require.NoError(t, SetupGauges(prometheus.NewRegistry(), prometheus.NewRegistry(), cfg.Metrics))
+	NewScraper(log, cfg, &http.Client{}).Run(ctx)
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
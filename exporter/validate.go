@@ -0,0 +1,94 @@
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Modes of --validate-queries.
+const (
+	ValidateQueriesOff     = "off"
+	ValidateQueriesWarn    = "warn"
+	ValidateQueriesStrict  = "strict"
+	ValidateQueriesDisable = "disable"
+)
+
+// invalidQuery is a metric whose JQL was rejected by Jira.
+type invalidQuery struct {
+	// metric is the index of the metric in the configuration.
+	metric int
+	err    error
+}
+
+// validationURL returns the URL asking Jira to strictly validate the JQL of
+// the given metric without returning any issues.
+func validationURL(cfg *Configuration, m MetricConfiguration) string {
+	params := url.Values{}
+	params.Set("jql", m.JQL)
+	params.Set("maxResults", "0")
+	params.Set("validateQuery", "strict")
+	return apiURL(cfg, params)
+}
+
+// validateQueries sends the JQL of every metric to the first endpoint once and returns the
+// metrics with invalid queries. Warnings reported for valid queries and
+// metrics that cannot be validated for other reasons, e.g. because Jira is
+// not reachable, are only logged.
+func validateQueries(ctx context.Context, log *logrus.Logger, cfg *Configuration, client *http.Client) []invalidQuery {
+	primary := endpointConfigs(cfg)[0]
+	s := newEndpointScraper(primary, client)
+	now := time.Now()
+	var invalid []invalidQuery
+	for idx, m := range cfg.Metrics {
+		if m.SkipValidation || m.DiscoverProjects != nil {
+			continue
+		}
+		rendered, err := renderMetric(m, cfg.Variables, now)
+		var pr pagedResponse
+		if err == nil {
+			pr, err = s.search(ctx, validationURL(primary, rendered), nil)
+		}
+		if err == nil {
+			if len(pr.WarningMessages) > 0 {
+				log.Warnf("Jira reported warnings for the JQL of %s: %s", metricID(m), strings.Join(pr.WarningMessages, "; "))
+			}
+			continue
+		}
+		if isInvalidJQL(err) {
+			invalid = append(invalid, invalidQuery{metric: idx, err: errors.Wrapf(err, "invalid JQL for metric %s", metricID(m))})
+			continue
+		}
+		log.WithError(err).Warnf("Failed to validate the JQL of %s", metricID(m))
+	}
+	return invalid
+}
+
+// markInvalidQueries records the errors of the metrics with invalid queries
+// so that SetupGauges doesn't register gauges for them.
+func markInvalidQueries(cfg *Configuration, invalid []invalidQuery) {
+	for _, q := range invalid {
+		cfg.Metrics[q.metric].InvalidQuery = q.err
+	}
+}
+
+// disableMetrics removes the metrics marked by markInvalidQueries from the
+// configuration so that they are never scraped. They are reported as failing
+// with invalid JQL instead.
+func disableMetrics(cfg *Configuration) {
+	metrics := make([]MetricConfiguration, 0, len(cfg.Metrics))
+	for _, m := range cfg.Metrics {
+		if m.InvalidQuery == nil {
+			metrics = append(metrics, m)
+			continue
+		}
+		setInvalidJQL(m, true)
+		cfg.scrapeStates.update(metricID(m), m.InvalidQuery)
+	}
+	cfg.Metrics = metrics
+}
@@ -0,0 +1,110 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateQueries(t *testing.T) {
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	var validated []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		require.Equal(t, "strict", q.Get("validateQuery"))
+		require.Equal(t, "0", q.Get("maxResults"))
+		validated = append(validated, q.Get("jql"))
+		if strings.Contains(q.Get("jql"), "foo") {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"errorMessages": ["Field 'foo' does not exist or you do not have permission to view it."]}`)
+			return
+		}
+		fmt.Fprint(w, `{"total": 1}`)
+	}))
+	defer srv.Close()
+	cfg := &Configuration{
+		BaseURL: srv.URL,
+		Metrics: []MetricConfiguration{
+			{Name: "valid", JQL: "project = TEST"},
+			{Name: "typo", JQL: "foo = bar"},
+			{Name: "dc_only", JQL: "issueFunction in foo()", SkipValidation: true},
+		},
+	}
+	errs := validateQueries(context.Background(), log, cfg, &http.Client{})
+	require.Equal(t, []string{"project = TEST", "foo = bar"}, validated)
+	require.Len(t, errs, 1)
+	require.Equal(t, 1, errs[0].metric)
+	require.Contains(t, errs[0].err.Error(), "invalid JQL for metric typo")
+	require.Contains(t, errs[0].err.Error(), "Field 'foo' does not exist")
+}
+
+func TestValidateQueriesDisable(t *testing.T) {
+	log, hook := logtest.NewNullLogger()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("jql") {
+		case "status = Closed":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"errorMessages": ["The value 'Closed' does not exist for the field 'status'."]}`)
+		case "fixVersion = 9.9":
+			fmt.Fprint(w, `{"total": 0, "warningMessages": ["The value '9.9' does not exist for the field 'fixVersion'."]}`)
+		default:
+			fmt.Fprint(w, `{"total": 1}`)
+		}
+	}))
+	defer srv.Close()
+	cfg, err := LoadConfiguration(writeConfig(t, fmt.Sprintf(`
+baseURL: %s
+metrics:
+  - name: closed
+    help: Closed issues
+    jql: status = Closed
+  - name: release
+    help: Issues of the next release
+    jql: fixVersion = 9.9
+  - name: open
+    help: Open issues
+    jql: resolution IS EMPTY
+`, srv.URL)), false)
+	require.NoError(t, err)
+
+	invalid := validateQueries(context.Background(), log, cfg, &http.Client{})
+	require.Len(t, invalid, 1)
+	require.Len(t, hook.AllEntries(), 1)
+	require.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+	require.Contains(t, hook.LastEntry().Message, "The value '9.9' does not exist")
+
+	markInvalidQueries(cfg, invalid)
+	reg := prometheus.NewRegistry()
+	require.NoError(t, SetupGauges(reg, reg, cfg.Metrics))
+	cfg.scrapeStates, err = setupScrapeStates(reg, cfg.Metrics)
+	require.NoError(t, err)
+	disableMetrics(cfg)
+	require.Len(t, cfg.Metrics, 2)
+	require.Equal(t, "release", cfg.Metrics[0].Name)
+	require.Equal(t, "open", cfg.Metrics[1].Name)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP jira_invalid_jql 1 if Jira rejected the JQL of the metric in the last scrape, 0 otherwise
+# TYPE jira_invalid_jql gauge
+jira_invalid_jql{name="closed"} 1
+jira_invalid_jql{name="open"} 0
+jira_invalid_jql{name="release"} 0
+`), "jira_invalid_jql"))
+	require.Equal(t, []string{"open", "release"}, cfg.scrapeStates.pending())
+
+	// The disabled metric doesn't export a value.
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, f := range families {
+		require.NotEqual(t, "jira_closed", f.GetName())
+	}
+}
@@ -0,0 +1,137 @@
+package exporter
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// otherGroup collects all group values rejected by a metric's value filter.
+const otherGroup = "other"
+
+// valueFilter limits the group values of a metric to bound its cardinality.
+// Patterns are either literal values or regular expressions enclosed in
+// slashes, e.g. /^team-.*$/.
+type valueFilter struct {
+	include []valuePattern
+	exclude []valuePattern
+}
+
+type valuePattern struct {
+	literal string
+	re      *regexp.Regexp
+}
+
+func (p valuePattern) matches(value string) bool {
+	if p.re != nil {
+		return p.re.MatchString(value)
+	}
+	return p.literal == value
+}
+
+func parseValuePatterns(patterns []string) ([]valuePattern, error) {
+	result := make([]valuePattern, 0, len(patterns))
+	for _, p := range patterns {
+		if len(p) >= 2 && strings.HasPrefix(p, "/") && strings.HasSuffix(p, "/") {
+			re, err := regexp.Compile(p[1 : len(p)-1])
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid pattern %s", p)
+			}
+			result = append(result, valuePattern{re: re})
+			continue
+		}
+		result = append(result, valuePattern{literal: p})
+	}
+	return result, nil
+}
+
+// newValueFilter returns the filter for the given include and exclude lists
+// or nil if both are empty.
+func newValueFilter(include []string, exclude []string) (*valueFilter, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil, nil
+	}
+	f := &valueFilter{}
+	var err error
+	if f.include, err = parseValuePatterns(include); err != nil {
+		return nil, errors.Wrap(err, "invalid includeValues")
+	}
+	if f.exclude, err = parseValuePatterns(exclude); err != nil {
+		return nil, errors.Wrap(err, "invalid excludeValues")
+	}
+	return f, nil
+}
+
+func (f *valueFilter) allowed(value string) bool {
+	if len(f.include) > 0 && !matchesAny(f.include, value) {
+		return false
+	}
+	return !matchesAny(f.exclude, value)
+}
+
+func matchesAny(patterns []valuePattern, value string) bool {
+	for _, p := range patterns {
+		if p.matches(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply replaces all values not allowed by the filter with otherGroup. An
+// issue is counted only once in otherGroup even if multiple of its values
+// were rejected. A nil filter allows all values.
+func (f *valueFilter) apply(values []string) []string {
+	if f == nil {
+		return values
+	}
+	result := make([]string, 0, len(values))
+	folded := false
+	for _, v := range values {
+		if f.allowed(v) {
+			result = append(result, v)
+			continue
+		}
+		if !folded {
+			result = append(result, otherGroup)
+			folded = true
+		}
+	}
+	return result
+}
+
+// topGroups keeps the n largest groups and adds the sum of all others to
+// otherGroup. Ties are broken by the group's name so that the same groups
+// are kept on every scrape. A non-positive n keeps all groups.
+func topGroups(groups map[string]float64, n int) map[string]float64 {
+	if n <= 0 || len(groups) <= n {
+		return groups
+	}
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		if name != otherGroup {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if groups[names[i]] != groups[names[j]] {
+			return groups[names[i]] > groups[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) <= n {
+		return groups
+	}
+	result := make(map[string]float64, n+1)
+	for _, name := range names[:n] {
+		result[name] = groups[name]
+	}
+	other := groups[otherGroup]
+	for _, name := range names[n:] {
+		other += groups[name]
+	}
+	result[otherGroup] = other
+	return result
+}
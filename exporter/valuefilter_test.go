@@ -0,0 +1,46 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueFilter(t *testing.T) {
+	g, err := lookupGrouper("fixVersions")
+	require.NoError(t, err)
+	filter, err := newValueFilter(nil, []string{"1.0", "/^legacy-/"})
+	require.NoError(t, err)
+	m := MetricConfiguration{valueFilter: filter}
+	counts := map[string]int{}
+	for _, i := range []issue{
+		{Fields: issueFields{FixVersions: []namedValue{{Name: "1.0"}, {Name: "2.0"}}}},
+		{Fields: issueFields{FixVersions: []namedValue{{Name: "legacy-a"}, {Name: "legacy-b"}}}},
+		{Fields: issueFields{FixVersions: []namedValue{{Name: "2.0"}}}},
+	} {
+		for _, v := range groupValues(g, i, m) {
+			counts[v]++
+		}
+	}
+	require.Equal(t, map[string]int{"2.0": 2, "other": 2}, counts)
+
+	filter, err = newValueFilter([]string{"/^2\\./"}, []string{"2.1"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"2.0", "other"}, filter.apply([]string{"2.0", "2.1", "1.0"}))
+
+	filter, err = newValueFilter(nil, nil)
+	require.NoError(t, err)
+	require.Nil(t, filter)
+	require.Equal(t, []string{"a"}, filter.apply([]string{"a"}))
+
+	_, err = newValueFilter([]string{"/(/"}, nil)
+	require.Error(t, err)
+}
+
+func TestTopGroups(t *testing.T) {
+	groups := map[string]float64{"a": 5, "b": 1, "c": 3, "d": 1, "other": 2}
+	require.Equal(t, map[string]float64{"a": 5, "c": 3, "other": 4}, topGroups(groups, 2))
+	require.Equal(t, map[string]float64{"a": 5, "b": 1, "c": 3, "other": 3}, topGroups(groups, 3))
+	require.Equal(t, groups, topGroups(groups, 0))
+	require.Equal(t, groups, topGroups(groups, 4))
+}
@@ -0,0 +1,101 @@
+package exporter
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// weightedCountConfiguration sums up a weight per issue instead of counting
+// issues, e.g. to compute a backlog score based on the issues' priorities.
+type weightedCountConfiguration struct {
+	// Field is the groupBy field whose values are weighted, e.g. priority.
+	Field   string             `yaml:"field"`
+	Weights map[string]float64 `yaml:"weights"`
+	// DefaultWeight is used for values without a weight and issues without
+	// any value.
+	DefaultWeight float64 `yaml:"defaultWeight"`
+
+	grouper grouper
+}
+
+func validateWeightedCount(m *MetricConfiguration) error {
+	w := m.WeightedCount
+	if w == nil {
+		return nil
+	}
+	if m.Type != "" && m.Type != metricTypeCount && m.Type != metricTypeSprint {
+		return errors.Errorf("weightedCount is not supported for %s metrics", m.Type)
+	}
+	if m.DiscoverProjects != nil {
+		return errors.New("weightedCount and discoverProjects must not be used together")
+	}
+	if w.Field == "" {
+		return errors.New("weightedCount.field is required")
+	}
+	var err error
+	if w.grouper, err = lookupGrouper(w.Field); err != nil {
+		return errors.Wrap(err, "invalid weightedCount.field")
+	}
+	return nil
+}
+
+// weight returns the weight of the given issue, which is the sum of the
+// weights of all its values. Values without a weight are added to unknown.
+func (w *weightedCountConfiguration) weight(i issue, m MetricConfiguration, unknown map[string]struct{}) float64 {
+	values := w.grouper.values(i, m)
+	if len(values) == 0 {
+		return w.DefaultWeight
+	}
+	sum := 0.0
+	for _, v := range values {
+		weight, ok := w.Weights[v]
+		if !ok {
+			unknown[v] = struct{}{}
+			weight = w.DefaultWeight
+		}
+		sum += weight
+	}
+	return sum
+}
+
+// scrapeWeighted sums up the weights of all issues matching the metric's
+// JQL, per group if the metric is grouped. Issues without any group are
+// summed up in Value.
+func (s *endpointScraper) scrapeWeighted(ctx context.Context, m MetricConfiguration) (scrapeResult, error) {
+	var g grouper
+	if m.GroupBy != "" {
+		var err error
+		if g, err = lookupGrouper(m.GroupBy); err != nil {
+			return scrapeResult{}, &scrapeError{reason: errorReasonRequest, err: err}
+		}
+	}
+	groups := make(map[string]float64)
+	unknown := make(map[string]struct{})
+	value := 0.0
+	result, err := s.fetchIssues(ctx, m, func(i issue) error {
+		weight := m.WeightedCount.weight(i, m, unknown)
+		if m.GroupBy == "" {
+			value += weight
+			return nil
+		}
+		values := groupValues(g, i, m)
+		if len(values) == 0 {
+			value += weight
+		}
+		for _, v := range values {
+			groups[v] += weight
+		}
+		return nil
+	})
+	result.Value = value
+	if m.GroupBy != "" {
+		result.Groups = topGroups(groups, m.TopN)
+	}
+	for v := range unknown {
+		result.UnknownWeights = append(result.UnknownWeights, v)
+	}
+	sort.Strings(result.UnknownWeights)
+	return result, err
+}
@@ -0,0 +1,57 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeightedCount(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "priority,status", r.URL.Query().Get("fields"))
+		fmt.Fprint(w, `{"total": 5, "issues": [
+			{"key": "A-1", "fields": {"priority": {"name": "Highest"}, "status": {"statusCategory": {"key": "new"}}}},
+			{"key": "A-2", "fields": {"priority": {"name": "High"}, "status": {"statusCategory": {"key": "new"}}}},
+			{"key": "A-3", "fields": {"priority": {"name": "Medium"}, "status": {"statusCategory": {"key": "indeterminate"}}}},
+			{"key": "A-4", "fields": {"priority": {"name": "Lowest"}, "status": {"statusCategory": {"key": "indeterminate"}}}},
+			{"key": "A-5", "fields": {"status": {"statusCategory": {"key": "indeterminate"}}}}
+		]}`)
+	}))
+	defer srv.Close()
+	cfg, err := LoadConfiguration(writeConfig(t, fmt.Sprintf(`
+baseURL: %s
+metrics:
+  - name: backlog_score
+    help: Weighted backlog score
+    jql: project = A
+    groupBy: statusCategory
+    weightedCount:
+      field: priority
+      weights: {Highest: 5, High: 3, Medium: 1}
+      defaultWeight: 0.5
+`, srv.URL)), false)
+	require.NoError(t, err)
+
+	s := newEndpointScraper(cfg, &http.Client{})
+	result, err := s.scrape(context.Background(), cfg.Metrics[0])
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"todo": 8, "inprogress": 2}, result.Groups)
+	require.Equal(t, []string{"Lowest"}, result.UnknownWeights)
+
+	m := cfg.Metrics[0]
+	m.GroupBy = ""
+	require.Equal(t, "priority", searchFields(m))
+
+	_, err = LoadConfiguration(writeConfig(t, `
+metrics:
+  - name: backlog_score
+    jql: project = A
+    weightedCount:
+      field: severity
+`), false)
+	require.Error(t, err)
+}
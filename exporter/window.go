@@ -0,0 +1,80 @@
+package exporter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// windowConfiguration restricts a metric to issues whose date field lies
+// within a trailing window, e.g. issues created during the last 7 days.
+type windowConfiguration struct {
+	Field          string        `yaml:"field"`
+	Duration       string        `yaml:"duration"`
+	ParsedDuration time.Duration `yaml:"-"`
+}
+
+var (
+	windowFieldPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+	orderByPattern     = regexp.MustCompile(`(?i)\border\s+by\b`)
+)
+
+// validateWindow parses the duration of the window. Jira's relative dates
+// don't support units below minutes.
+func validateWindow(w *windowConfiguration) error {
+	if !windowFieldPattern.MatchString(w.Field) {
+		return errors.Errorf("invalid window field %q", w.Field)
+	}
+	d, err := time.ParseDuration(w.Duration)
+	if err != nil {
+		return errors.Wrap(err, "invalid window duration")
+	}
+	if d < time.Minute || d%time.Minute != 0 {
+		return errors.Errorf("window duration %s must be a positive number of minutes", d)
+	}
+	w.ParsedDuration = d
+	return nil
+}
+
+// windowClause returns the JQL condition of the window using Jira's
+// relative date syntax, e.g. created >= -7d.
+func windowClause(w *windowConfiguration) string {
+	d := w.ParsedDuration
+	var value string
+	switch {
+	case d%(24*time.Hour) == 0:
+		value = fmt.Sprintf("-%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		value = fmt.Sprintf("-%dh", d/time.Hour)
+	default:
+		value = fmt.Sprintf("-%dm", d/time.Minute)
+	}
+	return fmt.Sprintf("%s >= %s", w.Field, value)
+}
+
+// applyWindow adds the window clause to the given JQL. Existing conditions
+// are put in parentheses and an ORDER BY is kept at the end.
+func applyWindow(jql string, w *windowConfiguration) string {
+	if w == nil {
+		return jql
+	}
+	return addCondition(jql, windowClause(w))
+}
+
+// addCondition combines the conditions of the JQL with the given one. An
+// ORDER BY is kept at the end.
+func addCondition(jql string, condition string) string {
+	conditions, orderBy := jql, ""
+	if loc := orderByPattern.FindAllStringIndex(jql, -1); len(loc) > 0 {
+		last := loc[len(loc)-1]
+		conditions, orderBy = jql[:last[0]], " "+strings.TrimSpace(jql[last[0]:])
+	}
+	conditions = strings.TrimSpace(conditions)
+	if conditions == "" {
+		return condition + orderBy
+	}
+	return fmt.Sprintf("(%s) AND %s%s", conditions, condition, orderBy)
+}
@@ -0,0 +1,45 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyWindow(t *testing.T) {
+	week := &windowConfiguration{Field: "created", ParsedDuration: 168 * time.Hour}
+	require.Equal(t, "(project = TEST) AND created >= -7d", applyWindow("project = TEST", week))
+	require.Equal(t, "(project = TEST OR labels = x) AND created >= -7d order by created DESC", applyWindow("project = TEST OR labels = x order by created DESC", week))
+	require.Equal(t, "created >= -7d ORDER BY key", applyWindow("ORDER BY key", week))
+	require.Equal(t, "project = TEST", applyWindow("project = TEST", nil))
+
+	require.Equal(t, "resolved >= -36h", windowClause(&windowConfiguration{Field: "resolved", ParsedDuration: 36 * time.Hour}))
+	require.Equal(t, "resolved >= -90m", windowClause(&windowConfiguration{Field: "resolved", ParsedDuration: 90 * time.Minute}))
+}
+
+func TestWindowConfiguration(t *testing.T) {
+	cfg, err := LoadConfiguration(writeConfig(t, `
+metrics:
+  - name: created_last_week
+    jql: project = {{ .project }}
+    window:
+      field: created
+      duration: 168h
+variables:
+  project: TEST
+`), false)
+	require.NoError(t, err)
+	rendered, err := renderMetric(cfg.Metrics[0], cfg.Variables, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, "(project = TEST) AND created >= -7d", rendered.JQL)
+
+	for _, window := range []string{
+		"{field: created, duration: 30s}",
+		"{field: created, duration: -1h}",
+		"{field: 'created OR 1', duration: 1h}",
+	} {
+		_, err := LoadConfiguration(writeConfig(t, "metrics:\n  - name: test\n    jql: project = TEST\n    window: "+window+"\n"), false)
+		require.Error(t, err, window)
+	}
+}
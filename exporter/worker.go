@@ -0,0 +1,91 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// workerRestartDelay is the time a worker waits before starting over after
+// a panic, so that a worker panicking right away doesn't spin.
+const workerRestartDelay = 10 * time.Second
+
+// setupWorkerMetrics registers the panic counter of every metric's worker
+// and returns the gauge counting the running workers.
+func setupWorkerMetrics(telemetry prometheus.Registerer, metrics []MetricConfiguration) (prometheus.Gauge, error) {
+	active := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "jiravars_active_workers",
+		Help: "Number of running metric workers",
+	})
+	if err := telemetry.Register(active); err != nil {
+		return nil, err
+	}
+	panics := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "jiravars_worker_panics_total",
+		Help: "Number of panics recovered in the worker of the metric",
+	}, []string{"metric"})
+	if err := telemetry.Register(panics); err != nil {
+		return nil, err
+	}
+	for i := range metrics {
+		metrics[i].Panics = panics.WithLabelValues(metricID(metrics[i]))
+	}
+	return active, nil
+}
+
+// recordPanic logs a recovered panic along with its stack trace.
+func recordPanic(log *logrus.Logger, m MetricConfiguration, recovered interface{}) {
+	if m.Panics != nil {
+		m.Panics.Inc()
+	}
+	log.WithField("stack", string(debug.Stack())).Errorf("Recovered from panic in the worker for %s: %v", metricID(m), recovered)
+}
+
+// superviseWorker runs the given worker until it returns normally or the
+// context is cancelled. A panicking worker is started over after delay.
+func superviseWorker(ctx context.Context, log *logrus.Logger, m MetricConfiguration, active prometheus.Gauge, delay time.Duration, work func()) {
+	for {
+		if !runWorker(log, m, active, work) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		log.Warnf("Restarting the worker for %s", metricID(m))
+	}
+}
+
+// runWorker runs the worker once and reports whether it panicked.
+func runWorker(log *logrus.Logger, m MetricConfiguration, active prometheus.Gauge, work func()) (panicked bool) {
+	if active != nil {
+		active.Inc()
+		defer active.Dec()
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			recordPanic(log, m, recovered)
+			panicked = true
+		}
+	}()
+	work()
+	return false
+}
+
+// recoverScrape runs a single scrape, turning a panic into an error so that
+// the worker keeps going.
+func recoverScrape(log *logrus.Logger, m MetricConfiguration, scrape func() error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			recordPanic(log, m, recovered)
+			setUp(m, false)
+			err = fmt.Errorf("scrape panicked: %v", recovered)
+		}
+	}()
+	return scrape()
+}
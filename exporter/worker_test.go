@@ -0,0 +1,59 @@
+package exporter
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuperviseWorker(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics := []MetricConfiguration{{Name: "test"}}
+	active, err := setupWorkerMetrics(reg, metrics)
+	require.NoError(t, err)
+	m := metrics[0]
+	log, hook := logtest.NewNullLogger()
+
+	runs := 0
+	superviseWorker(context.Background(), log, m, active, time.Millisecond, func() {
+		runs++
+		require.Equal(t, 1.0, testutil.ToFloat64(active))
+		if runs < 3 {
+			var groups map[string]int
+			groups["boom"]++
+		}
+	})
+	require.Equal(t, 3, runs)
+	require.Equal(t, 2.0, testutil.ToFloat64(m.Panics))
+	require.Equal(t, 0.0, testutil.ToFloat64(active))
+	require.Contains(t, hook.AllEntries()[0].Message, "Recovered from panic in the worker for test")
+	require.Contains(t, hook.AllEntries()[0].Data["stack"], "superviseWorker")
+
+	// Cancelling the context stops restarting the worker.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	runs = 0
+	superviseWorker(ctx, log, m, active, time.Hour, func() {
+		runs++
+		panic("boom")
+	})
+	require.Equal(t, 1, runs)
+}
+
+func TestRecoverScrape(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	m := MetricConfiguration{Name: "test", Up: prometheus.NewGauge(prometheus.GaugeOpts{Name: "up"})}
+	m.Up.Set(1)
+	err := recoverScrape(log, m, func() error { panic("boom") })
+	require.EqualError(t, err, "scrape panicked: boom")
+	require.Equal(t, 0.0, testutil.ToFloat64(m.Up))
+	require.NoError(t, recoverScrape(log, m, func() error { return nil }))
+}